@@ -0,0 +1,303 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type AccountTransactionsRepo interface {
+	// Init will initialise our account transactions repo.
+	Init() error
+
+	// WithTx will return a copy of the repo that runs its queries in the transaction.
+	WithTx(tx *sql.Tx) AccountTransactionsRepo
+
+	// List will return a collection of account transactions, most recent first.
+	List(filter *types.ListAccountTransactionsFilter, pageSize int, pageToken string) ([]*types.AccountTransaction, string, error)
+
+	// Create will record a change to a channel account's balance.
+	Create(transaction *types.AccountTransaction) error
+
+	// RecordIncomeAndDecay will record the daily income and decay for each account it is about to be applied to.
+	RecordIncomeAndDecay(decay *types.DecayPolicy, incomeToApply int) error
+
+	// SeedOpeningBalances will record the current balance of any account that doesn't have a transaction yet.
+	SeedOpeningBalances() (int64, error)
+
+	// Prune will remove transactions older than the provided time, carrying their total forward for each account.
+	Prune(olderThan time.Time) (int64, error)
+
+	// Unreconciled will return accounts whose balance doesn't match the sum of their transactions.
+	Unreconciled(limit int) ([]*types.AccountReconciliation, error)
+}
+
+type accountTransactionsRepo struct {
+	db  querier
+	log *logrus.Logger
+}
+
+func NewAccountTransactionsRepo(
+	db *sql.DB,
+	log *logrus.Logger,
+) AccountTransactionsRepo {
+	return &accountTransactionsRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Init initialises the account transactions repo.
+func (r *accountTransactionsRepo) Init() error {
+	return nil
+}
+
+// WithTx returns a copy of the repo that runs its queries in the transaction.
+func (r *accountTransactionsRepo) WithTx(tx *sql.Tx) AccountTransactionsRepo {
+	return &accountTransactionsRepo{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+// List will retrieve and list account transactions matching the provided criteria.
+func (r *accountTransactionsRepo) List(
+	filter *types.ListAccountTransactionsFilter,
+	pageSize int,
+	pageToken string,
+) ([]*types.AccountTransaction, string, error) {
+	var args []interface{}
+	var query = getAccountTransactionQueries()[accountTransactionsList]
+
+	// Prepare query
+	query, args = r.applyFilter(query, filter, pageSize, pageToken)
+
+	// Execute the query
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Parse rows
+	transactions, err := r.scanAccountTransactions(rows)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// No results
+	if len(transactions) == 0 {
+		return transactions, "", nil
+	}
+
+	return transactions, fmt.Sprint(transactions[len(transactions)-1].Id), nil
+}
+
+// Create will record a change to a channel account's balance. The team is taken from the account.
+func (r *accountTransactionsRepo) Create(transaction *types.AccountTransaction) error {
+	if _, err := r.db.Exec(
+		getAccountTransactionQueries()[accountTransactionCreate],
+		transaction.Type,
+		transaction.Amount,
+		transaction.Reason,
+		transaction.MessageId,
+		transaction.ChannelAccountId,
+	); err != nil {
+		return errors.Wrapf(err, "failed to record %v transaction for account: %v", transaction.Type, transaction.ChannelAccountId)
+	}
+
+	return nil
+}
+
+// RecordIncomeAndDecay will record the daily income and decay. It must be called before they're applied as the amounts
+// are worked out from the current balances. Channels with their own settings use them instead of the provided defaults.
+func (r *accountTransactionsRepo) RecordIncomeAndDecay(decay *types.DecayPolicy, incomeToApply int) error {
+	afterDecay, afterIncome := incomeAndDecayExpressions(decay, incomeToApply)
+
+	if _, err := r.db.Exec(
+		fmt.Sprintf(getAccountTransactionQueries()[accountTransactionsRecordDecay], afterDecay, afterIncome),
+		types.AccountTransactionTypeDecay,
+		"daily decay",
+	); err != nil {
+		return errors.Wrap(err, "failed to record daily decay transactions")
+	}
+
+	if _, err := r.db.Exec(
+		fmt.Sprintf(getAccountTransactionQueries()[accountTransactionsRecordIncome], afterDecay, afterIncome),
+		types.AccountTransactionTypeIncome,
+		"daily income",
+	); err != nil {
+		return errors.Wrap(err, "failed to record daily income transactions")
+	}
+
+	return nil
+}
+
+// SeedOpeningBalances will record the current balance of any account that doesn't have a transaction yet. This allows
+// the ledger to be enabled for existing accounts.
+func (r *accountTransactionsRepo) SeedOpeningBalances() (int64, error) {
+	res, err := r.db.Exec(
+		getAccountTransactionQueries()[accountTransactionsSeedOpeningBalances],
+		types.AccountTransactionTypeAdjustment,
+		"opening balance",
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to seed opening balances")
+	}
+
+	return res.RowsAffected()
+}
+
+// Prune will remove transactions older than the provided time. The total of the removed transactions is carried forward
+// as a single adjustment for each account so that the ledger still sums to the balance.
+func (r *accountTransactionsRepo) Prune(olderThan time.Time) (int64, error) {
+	// The carried forward row is created at the cut off so that it's included in the next prune.
+	if _, err := r.db.Exec(
+		getAccountTransactionQueries()[accountTransactionsCarryForward],
+		types.AccountTransactionTypeAdjustment,
+		"carried forward",
+		olderThan,
+		olderThan,
+	); err != nil {
+		return 0, errors.Wrap(err, "failed to carry forward account transactions")
+	}
+
+	res, err := r.db.Exec(
+		getAccountTransactionQueries()[accountTransactionsDeleteOlderThan],
+		olderThan,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to delete old account transactions")
+	}
+
+	return res.RowsAffected()
+}
+
+// Unreconciled will return accounts whose balance doesn't match the sum of their transactions.
+func (r *accountTransactionsRepo) Unreconciled(limit int) ([]*types.AccountReconciliation, error) {
+	rows, err := r.db.Query(
+		getAccountTransactionQueries()[accountTransactionsUnreconciledAccounts],
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile account transactions")
+	}
+
+	defer rows.Close()
+
+	var res []*types.AccountReconciliation
+	for rows.Next() {
+		var reconciliation types.AccountReconciliation
+		if err = rows.Scan(
+			&reconciliation.ChannelAccountId,
+			&reconciliation.Balance,
+			&reconciliation.LedgerBalance,
+		); err != nil {
+			return nil, err
+		}
+
+		res = append(res, &reconciliation)
+	}
+
+	return res, nil
+}
+
+func (r *accountTransactionsRepo) applyFilter(
+	query string,
+	filter *types.ListAccountTransactionsFilter,
+	pageSize int,
+	pageToken string,
+) (string, []interface{}) {
+	var (
+		clauses []string
+		args    []interface{}
+	)
+
+	if filter == nil {
+		return query, args
+	}
+
+	// Filter by team id if provided
+	if filter.TeamId != "" {
+		clauses = append(clauses, "team_id = ?")
+		args = append(args, filter.TeamId)
+	}
+
+	// Filter by channel account id if provided
+	if filter.ChannelAccountId != 0 {
+		clauses = append(clauses, "channel_account_id = ?")
+		args = append(args, filter.ChannelAccountId)
+	}
+
+	// Filter by type if provided
+	if filter.Type != "" {
+		clauses = append(clauses, "type = ?")
+		args = append(args, filter.Type)
+	}
+
+	// Filter by message id if provided
+	if filter.MessageId != "" {
+		clauses = append(clauses, "message_id = ?")
+		args = append(args, filter.MessageId)
+	}
+
+	if len(clauses) != 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	// Validate page size
+	if pageSize > 100 || pageSize <= 0 {
+		pageSize = 100
+	}
+
+	query += " ORDER BY id DESC"
+
+	pageTokenI, err := strconv.Atoi(pageToken)
+	if err == nil {
+		query += fmt.Sprintf(" LIMIT %v, %v", pageTokenI, pageSize)
+	} else {
+		query += fmt.Sprintf(" LIMIT 0, %v", pageSize)
+	}
+
+	return query, args
+}
+
+// scanAccountTransactions populates a slice of structs from db rows
+func (r *accountTransactionsRepo) scanAccountTransactions(rows *sql.Rows) ([]*types.AccountTransaction, error) {
+	defer rows.Close()
+
+	var res []*types.AccountTransaction
+
+	for rows.Next() {
+		var (
+			transaction types.AccountTransaction
+			created     time.Time
+		)
+
+		if err := rows.Scan(
+			&transaction.Id,
+			&transaction.TeamId,
+			&transaction.ChannelAccountId,
+			&transaction.Type,
+			&transaction.Amount,
+			&transaction.Reason,
+			&transaction.MessageId,
+			&created,
+		); err != nil {
+			return nil, err
+		}
+
+		// Assign timestamps
+		transaction.Created = *timestamppb.New(created)
+
+		res = append(res, &transaction)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type AchievementsRepo interface {
+	// Init will initialise our achievements repo.
+	Init() error
+
+	// WithTx will return a copy of the repo that runs its queries in the transaction.
+	WithTx(tx *sql.Tx) AchievementsRepo
+
+	// Create will record an achievement.
+	Create(achievement *types.Achievement) error
+
+	// Counts will return how many times the user has earned each achievement in the channel.
+	Counts(teamId string, channelId string, userId string) ([]*types.AchievementCount, error)
+}
+
+type achievementsRepo struct {
+	db  querier
+	log *logrus.Logger
+}
+
+func NewAchievementsRepo(
+	db *sql.DB,
+	log *logrus.Logger,
+) AchievementsRepo {
+	return &achievementsRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Init initialises the achievements repo.
+func (r *achievementsRepo) Init() error {
+	return nil
+}
+
+// WithTx returns a copy of the repo that runs its queries in the transaction.
+func (r *achievementsRepo) WithTx(tx *sql.Tx) AchievementsRepo {
+	return &achievementsRepo{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+// Create will record an achievement.
+func (r *achievementsRepo) Create(achievement *types.Achievement) error {
+	if _, err := r.db.Exec(
+		getAchievementQueries()[achievementCreate],
+		achievement.TeamId,
+		achievement.ChannelId,
+		achievement.UserId,
+		achievement.Type,
+		achievement.MessageId,
+	); err != nil {
+		return errors.Wrap(err, "failed to create achievement")
+	}
+
+	return nil
+}
+
+// Counts will return how many times the user has earned each achievement in the channel along with when it was last
+// earned. Achievements the user hasn't earned aren't included.
+func (r *achievementsRepo) Counts(teamId string, channelId string, userId string) ([]*types.AchievementCount, error) {
+	rows, err := r.db.Query(
+		getAchievementQueries()[achievementsCounts],
+		teamId,
+		channelId,
+		userId,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count achievements")
+	}
+
+	var counts []*types.AchievementCount
+	for rows.Next() {
+		var count types.AchievementCount
+		if err = rows.Scan(&count.Type, &count.Count, &count.LastEarned); err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, &count)
+	}
+
+	return counts, nil
+}
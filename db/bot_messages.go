@@ -48,6 +48,7 @@ func (r *botMessagesRepo) Init() error {
 func (r *botMessagesRepo) Create(botMessage *types.BotMessage) error {
 	var _, err = r.db.Exec(
 		getBotMessageQueries()[botMessageCreate],
+		botMessage.TeamId,
 		botMessage.SentMessageId,
 		botMessage.MessageId,
 		botMessage.ChannelId,
@@ -130,6 +131,12 @@ func (r *botMessagesRepo) applyFilter(
 		args = append(args, filter.Id)
 	}
 
+	// Filter by team id
+	if filter.TeamId != "" {
+		clauses = append(clauses, "team_id = ?")
+		args = append(args, filter.TeamId)
+	}
+
 	// Filter by message id
 	if filter.MessageId != "" {
 		clauses = append(clauses, "message_id = ?")
@@ -200,6 +207,7 @@ func scanBotMessages(rows *sql.Rows) ([]*types.BotMessage, error) {
 
 		if err := rows.Scan(
 			&botMessage.Id,
+			&botMessage.TeamId,
 			&botMessage.MessageId,
 			&botMessage.SentMessageId,
 			&botMessage.ChannelId,
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/buzzology/slack_bot/types"
@@ -14,6 +15,9 @@ type BotStateRepo interface {
 	// Init will initialise our bot state repo.
 	Init() error
 
+	// WithTx will return a copy of the repo that runs its queries in the transaction.
+	WithTx(tx *sql.Tx) BotStateRepo
+
 	// Get will retrieve our bot state.
 	Get() (*types.BotState, error)
 
@@ -26,22 +30,26 @@ type BotStateRepo interface {
 	// Update the bot's state.
 	Update(*types.BotState) (*types.BotState, error)
 
-	// ApplyIncomeAndDecay will decrement all users incomes by the decay and increment by the income.
-	ApplyIncomeAndDecay(decayToApply int, incomeToApply int) error
+	// ApplyIncomeAndDecay will decay all users balances and then increment them by the income.
+	ApplyIncomeAndDecay(decay *types.DecayPolicy, incomeToApply int) error
 }
 
 type botStateRepo struct {
-	db  *sql.DB
+	db  querier
 	log *logrus.Logger
+	// transactionsRepo records the income and decay, it is nil when the ledger is disabled.
+	transactionsRepo AccountTransactionsRepo
 }
 
 func NewBotStateRepo(
 	db *sql.DB,
 	log *logrus.Logger,
+	transactionsRepo AccountTransactionsRepo,
 ) BotStateRepo {
 	return &botStateRepo{
-		db:  db,
-		log: log,
+		db:               db,
+		log:              log,
+		transactionsRepo: transactionsRepo,
 	}
 }
 
@@ -50,6 +58,20 @@ func (r *botStateRepo) Init() error {
 	return nil
 }
 
+// WithTx returns a copy of the repo that runs its queries in the transaction.
+func (r *botStateRepo) WithTx(tx *sql.Tx) BotStateRepo {
+	var transactionsRepo AccountTransactionsRepo
+	if r.transactionsRepo != nil {
+		transactionsRepo = r.transactionsRepo.WithTx(tx)
+	}
+
+	return &botStateRepo{
+		db:               tx,
+		log:              r.log,
+		transactionsRepo: transactionsRepo,
+	}
+}
+
 // Update the bot's state.
 func (r *botStateRepo) Update(botState *types.BotState) (*types.BotState, error) {
 	var _, err = r.db.Exec(
@@ -58,6 +80,7 @@ func (r *botStateRepo) Update(botState *types.BotState) (*types.BotState, error)
 		botState.WeekTickover.AsTime(),
 		botState.MonthTickover.AsTime(),
 		botState.YearTickover.AsTime(),
+		botState.Season,
 		botState.Id,
 	)
 
@@ -106,7 +129,7 @@ func (r *botStateRepo) Tickover(decay int, income int) (*types.BotState, error)
 		botState.DayTickover = *timestamppb.New(botState.DayTickover.AsTime().Add(time.Hour * 24))
 
 		// We apply decay and income daily as well.
-		if err = r.ApplyIncomeAndDecay(decay, income); err != nil {
+		if err = r.ApplyIncomeAndDecay(&types.DecayPolicy{Mode: types.DecayModeFlat, Amount: decay}, income); err != nil {
 			return nil, err
 		}
 	}
@@ -191,6 +214,7 @@ func (r *botStateRepo) scanBotStates(rows *sql.Rows) ([]*types.BotState, error)
 			&weekTickover,
 			&monthTickover,
 			&yearTickover,
+			&botState.Season,
 		); err != nil {
 
 			if err == sql.ErrNoRows {
@@ -213,14 +237,23 @@ func (r *botStateRepo) scanBotStates(rows *sql.Rows) ([]*types.BotState, error)
 	return res, nil
 }
 
-// ApplyIncomeAndDecay will apply income and decay to all channel
+// ApplyIncomeAndDecay will apply the decay and then the income to all channel accounts. Decay stops at zero and the
+// income is capped at the policy's max balance. Channels with their own settings use them instead of the provided
+// defaults.
 func (r *botStateRepo) ApplyIncomeAndDecay(
-	decayToApply int,
+	decay *types.DecayPolicy,
 	incomeToApply int,
 ) error {
-	var query = getChannelAccountQueries()[channelAccountApplyIncomeAndDecay]
+	afterDecay, afterIncome := incomeAndDecayExpressions(decay, incomeToApply)
+
+	// Record the transactions first as the balances they're applied to will change.
+	if r.transactionsRepo != nil {
+		if err := r.transactionsRepo.RecordIncomeAndDecay(decay, incomeToApply); err != nil {
+			return err
+		}
+	}
 
 	// Execute the query
-	_, err := r.db.Exec(query, decayToApply, incomeToApply, decayToApply, incomeToApply)
+	_, err := r.db.Exec(fmt.Sprintf(getChannelAccountQueries()[channelAccountApplyIncomeAndDecay], afterDecay, afterIncome))
 	return err
 }
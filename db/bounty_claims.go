@@ -0,0 +1,301 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type BountyClaimsRepo interface {
+	// Init will initialise our bounty claims repo.
+	Init() error
+
+	// WithTx will return a copy of the repo that runs its queries in the transaction.
+	WithTx(tx *sql.Tx) BountyClaimsRepo
+
+	// List will return a collection of bounty claims, in the order they were claimed.
+	List(filter *types.ListBountyClaimsFilter, pageSize int, pageToken string) ([]*types.BountyClaim, string, error)
+
+	// Upsert will record a user's claim on a bounty, updating it if they've claimed it before.
+	Upsert(claim *types.BountyClaim) error
+
+	// AwardedCount will count the bounties awarded in the channel (every channel if empty) since the provided time, only
+	// those awarded to the user if one is provided.
+	AwardedCount(teamId string, channelId string, userId string, since time.Time) (int, error)
+
+	// BonusSince will return the total fast review bonus the user has been paid in the channel since the provided time.
+	BonusSince(teamId string, channelId string, userId string, since time.Time) (int, error)
+
+	// AwardedDays will return each day since the provided time that the user was awarded a bounty in the channel (every
+	// channel if empty), most recent first.
+	AwardedDays(teamId string, channelId string, userId string, since time.Time) ([]time.Time, error)
+}
+
+type bountyClaimsRepo struct {
+	db  querier
+	log *logrus.Logger
+}
+
+func NewBountyClaimsRepo(
+	db *sql.DB,
+	log *logrus.Logger,
+) BountyClaimsRepo {
+	return &bountyClaimsRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Init initialises the bounty claims repo.
+func (r *bountyClaimsRepo) Init() error {
+	return nil
+}
+
+// WithTx returns a copy of the repo that runs its queries in the transaction.
+func (r *bountyClaimsRepo) WithTx(tx *sql.Tx) BountyClaimsRepo {
+	return &bountyClaimsRepo{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+// List will retrieve and list bounty claims matching the provided criteria.
+func (r *bountyClaimsRepo) List(
+	filter *types.ListBountyClaimsFilter,
+	pageSize int,
+	pageToken string,
+) ([]*types.BountyClaim, string, error) {
+	var args []interface{}
+	var query = getBountyClaimQueries()[bountyClaimsList]
+
+	// Prepare query
+	query, args = r.applyFilter(query, filter, pageSize, pageToken)
+
+	// Execute the query
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Parse rows
+	claims, err := r.scanBountyClaims(rows)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// No results
+	if len(claims) == 0 {
+		return claims, "", nil
+	}
+
+	return claims, fmt.Sprint(claims[len(claims)-1].Id), nil
+}
+
+// Upsert will record a user's claim on a bounty. A user only has one claim per bounty so claiming it again updates
+// the status, amount and bonus of their existing claim. The claim time is reset when an inactive claim is made active.
+func (r *bountyClaimsRepo) Upsert(claim *types.BountyClaim) error {
+	if _, err := r.db.Exec(
+		getBountyClaimQueries()[bountyClaimUpsert],
+		claim.TeamId,
+		claim.MessageId,
+		claim.ChannelId,
+		claim.UserId,
+		claim.Status,
+		claim.Amount,
+		claim.Bonus,
+		types.BountyClaimStatusActive,
+		types.BountyClaimStatusActive,
+	); err != nil {
+		return errors.Wrapf(err, "failed to upsert bounty claim: %v, %v", claim.MessageId, claim.UserId)
+	}
+
+	return nil
+}
+
+// AwardedCount will count the bounties awarded in the channel since the provided time, only those awarded to the user
+// if one is provided. Each bounty is only counted once however many claims it was split between.
+func (r *bountyClaimsRepo) AwardedCount(teamId string, channelId string, userId string, since time.Time) (int, error) {
+	var count int
+	if err := r.db.QueryRow(
+		getBountyClaimQueries()[bountyClaimsAwardedCount],
+		teamId,
+		channelId,
+		channelId,
+		userId,
+		userId,
+		types.BountyClaimStatusAwarded,
+		since,
+	).Scan(&count); err != nil {
+		return 0, errors.Wrap(err, "failed to count awarded bounties")
+	}
+
+	return count, nil
+}
+
+// BonusSince will return the total fast review bonus the user has been paid in the channel since the provided time.
+func (r *bountyClaimsRepo) BonusSince(teamId string, channelId string, userId string, since time.Time) (int, error) {
+	var total int
+	if err := r.db.QueryRow(
+		getBountyClaimQueries()[bountyClaimsBonusSince],
+		teamId,
+		channelId,
+		userId,
+		types.BountyClaimStatusAwarded,
+		since,
+	).Scan(&total); err != nil {
+		return 0, errors.Wrap(err, "failed to total fast review bonuses")
+	}
+
+	return total, nil
+}
+
+// AwardedDays will return each day since the provided time that the user was awarded a bounty in the channel, most
+// recent first.
+func (r *bountyClaimsRepo) AwardedDays(teamId string, channelId string, userId string, since time.Time) ([]time.Time, error) {
+	rows, err := r.db.Query(
+		getBountyClaimQueries()[bountyClaimsAwardedDays],
+		teamId,
+		channelId,
+		channelId,
+		userId,
+		types.BountyClaimStatusAwarded,
+		since,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the days bounties were awarded")
+	}
+
+	defer rows.Close()
+
+	var days []time.Time
+	for rows.Next() {
+		var day time.Time
+		if err = rows.Scan(&day); err != nil {
+			return nil, err
+		}
+
+		days = append(days, day)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to list the days bounties were awarded")
+	}
+
+	return days, nil
+}
+
+func (r *bountyClaimsRepo) applyFilter(
+	query string,
+	filter *types.ListBountyClaimsFilter,
+	pageSize int,
+	pageToken string,
+) (string, []interface{}) {
+	var (
+		clauses []string
+		args    []interface{}
+	)
+
+	if filter == nil {
+		return query, args
+	}
+
+	// Filter by team id if provided
+	if filter.TeamId != "" {
+		clauses = append(clauses, "team_id = ?")
+		args = append(args, filter.TeamId)
+	}
+
+	// Filter by message id if provided
+	if filter.MessageId != "" {
+		clauses = append(clauses, "message_id = ?")
+		args = append(args, filter.MessageId)
+	}
+
+	// Filter by channel id if provided
+	if filter.ChannelId != "" {
+		clauses = append(clauses, "channel_id = ?")
+		args = append(args, filter.ChannelId)
+	}
+
+	// Filter by user id if provided
+	if filter.UserId != "" {
+		clauses = append(clauses, "user_id = ?")
+		args = append(args, filter.UserId)
+	}
+
+	// Filter by status if provided
+	if filter.Status > 0 {
+		clauses = append(clauses, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	if len(clauses) != 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	// Validate page size
+	if pageSize > 100 || pageSize <= 0 {
+		pageSize = 100
+	}
+
+	// Oldest claims first so that shares line up with the order the bounty was claimed in. Reclaiming keeps the same
+	// row so the id can't be used for this.
+	query += " ORDER BY claimed_at ASC, id ASC"
+
+	pageTokenI, err := strconv.Atoi(pageToken)
+	if err == nil {
+		query += fmt.Sprintf(" LIMIT %v, %v", pageTokenI, pageSize)
+	} else {
+		query += fmt.Sprintf(" LIMIT 0, %v", pageSize)
+	}
+
+	return query, args
+}
+
+// scanBountyClaims populates a slice of structs from db rows
+func (r *bountyClaimsRepo) scanBountyClaims(rows *sql.Rows) ([]*types.BountyClaim, error) {
+	defer rows.Close()
+
+	var res []*types.BountyClaim
+
+	for rows.Next() {
+		var (
+			claim     types.BountyClaim
+			claimedAt time.Time
+			created   time.Time
+			updated   time.Time
+		)
+
+		if err := rows.Scan(
+			&claim.Id,
+			&claim.TeamId,
+			&claim.MessageId,
+			&claim.ChannelId,
+			&claim.UserId,
+			&claim.Status,
+			&claim.Amount,
+			&claim.Bonus,
+			&claimedAt,
+			&created,
+			&updated,
+		); err != nil {
+			return nil, err
+		}
+
+		// Assign timestamps
+		claim.ClaimedAt = *timestamppb.New(claimedAt)
+		claim.Created = *timestamppb.New(created)
+		claim.Updated = *timestamppb.New(updated)
+
+		res = append(res, &claim)
+	}
+
+	return res, nil
+}
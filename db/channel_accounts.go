@@ -8,14 +8,21 @@ import (
 	"time"
 
 	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// ErrInsufficientFunds is returned when an account's balance is too low for a spend.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
 type ChannelAccountsRepo interface {
 	// Init will initialise our account transactions repo.
 	Init() error
 
+	// WithTx will return a copy of the repo that runs its queries in the transaction.
+	WithTx(tx *sql.Tx) ChannelAccountsRepo
+
 	// List will return a collection of account transactions.
 	List(filter *types.ListChannelAccountsFilter, pageSize int, pageToken string, order string) ([]*types.ChannelAccount, string, error)
 
@@ -25,35 +32,52 @@ type ChannelAccountsRepo interface {
 	// Update will update an existing channel account.
 	Update(channelAccount *types.ChannelAccount) (*types.ChannelAccount, error)
 
-	// Spend will update a channel account to reflect a new spend amount.
-	Spend(id int, amount int) error
+	// Spend will update a channel account to reflect a new spend amount. ErrInsufficientFunds is returned if the balance
+	// isn't high enough.
+	Spend(id int, amount int, messageId string) error
 
 	// Award will update a channel account to reflect a new award amount.
-	Award(id int, amount int) error
+	Award(id int, amount int, messageId string) error
+
+	// Refund will return a previous spend to a channel account.
+	Refund(id int, amount int, messageId string) error
+
+	// AwardBonus will add a bonus to a channel account, it's counted as earned in the same way as an award.
+	AwardBonus(id int, amount int, messageId string) error
+
+	// Transfer will move points from one channel account to another. ErrInsufficientFunds is returned if the sender's
+	// balance isn't high enough. It should be run in a transaction so that the points can't be lost part way through.
+	Transfer(fromId int, toId int, amount int) error
 
 	// ActiveTodayCount will count the number of accounts in the channel that are active today.
-	ActiveTodayCount(channelId string) (int, error)
+	ActiveTodayCount(teamId string, channelId string) (int, error)
 
 	// ActiveThisWeekCount will count the number of accounts in the channel that have been active this week.
-	ActiveThisWeekCount(channelId string) (int, error)
+	ActiveThisWeekCount(teamId string, channelId string) (int, error)
 
 	// ActiveThisYearCount will count the number of accounts in the channel that have been active this year.
-	ActiveThisYearCount(channelId string) (int, error)
+	ActiveThisYearCount(teamId string, channelId string) (int, error)
 
 	// ActiveAllTimeCount will count the number of accounts in the channel that have been active all time.
-	ActiveAllTimeCount(channelId string) (int, error)
+	ActiveAllTimeCount(teamId string, channelId string) (int, error)
+
+	// ActiveThisSeasonCount will count the number of accounts in the channel that have been active this season.
+	ActiveThisSeasonCount(teamId string, channelId string) (int, error)
 
 	// LeadersToday will count the number of leading accounts for today.
-	LeadersToday(channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error)
+	LeadersToday(teamId string, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error)
 
 	// LeadersThisWeek will count the number of leading accounts for today.
-	LeadersThisWeek(channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error)
+	LeadersThisWeek(teamId string, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error)
 
 	// LeadersThisYear will count the number of leading accounts for today.
-	LeadersThisYear(channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error)
+	LeadersThisYear(teamId string, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error)
 
 	// LeadersAllTime will count the number of leading accounts for today.
-	LeadersAllTime(channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error)
+	LeadersAllTime(teamId string, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error)
+
+	// LeadersThisSeason will return the leading accounts for the current season.
+	LeadersThisSeason(teamId string, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error)
 
 	// ResetDaily will reset daily tracking for all channel accounts.
 	ResetDaily() error
@@ -64,22 +88,39 @@ type ChannelAccountsRepo interface {
 	// ResetYearly will reset yearly tracking for all channel accounts.
 	ResetYearly() error
 
+	// ResetSeason will reset season tracking for all channel accounts.
+	ResetSeason() error
+
+	// ResetSeasonBalances will remove the percentage of every balance (rounded down) at the start of a season.
+	ResetSeasonBalances(percentage int) error
+
+	// SetFrozen will freeze or unfreeze a user's account in a channel, or in every channel if no channel is provided.
+	SetFrozen(teamId string, userId string, channelId string, frozen bool) error
+
 	// DistinctChannels will return a list of all distinct channels.
-	DistinctChannels() ([]string, error)
+	DistinctChannels() ([]*types.TeamChannel, error)
+
+	// MergeIntoWorkspaceWallets will fold each user's channel accounts into their workspace wallet. It should be run in
+	// a transaction so that balances can't be merged twice.
+	MergeIntoWorkspaceWallets() (int64, error)
 }
 
 type channelAccountsRepo struct {
-	db  *sql.DB
+	db  querier
 	log *logrus.Logger
+	// transactionsRepo records each balance change, it is nil when the ledger is disabled.
+	transactionsRepo AccountTransactionsRepo
 }
 
 func NewChannelAccountsRepo(
 	db *sql.DB,
 	log *logrus.Logger,
+	transactionsRepo AccountTransactionsRepo,
 ) ChannelAccountsRepo {
 	return &channelAccountsRepo{
-		db:  db,
-		log: log,
+		db:               db,
+		log:              log,
+		transactionsRepo: transactionsRepo,
 	}
 }
 
@@ -88,6 +129,20 @@ func (r *channelAccountsRepo) Init() error {
 	return nil
 }
 
+// WithTx returns a copy of the repo that runs its queries in the transaction.
+func (r *channelAccountsRepo) WithTx(tx *sql.Tx) ChannelAccountsRepo {
+	var transactionsRepo AccountTransactionsRepo
+	if r.transactionsRepo != nil {
+		transactionsRepo = r.transactionsRepo.WithTx(tx)
+	}
+
+	return &channelAccountsRepo{
+		db:               tx,
+		log:              r.log,
+		transactionsRepo: transactionsRepo,
+	}
+}
+
 // List will retrieve and list channel accounts matching the provided criteria.
 func (r *channelAccountsRepo) List(
 	filter *types.ListChannelAccountsFilter,
@@ -129,6 +184,7 @@ func (r *channelAccountsRepo) Create(
 ) (*types.ChannelAccount, error) {
 	var res, err = r.db.Exec(
 		getChannelAccountQueries()[channelAccountCreate],
+		channelAccount.TeamId,
 		channelAccount.UserId,
 		channelAccount.ChannelId,
 		channelAccount.Balance,
@@ -160,6 +216,10 @@ func (r *channelAccountsRepo) Create(
 		return nil, fmt.Errorf("failed to retrieve new channel_account: %v", id)
 	}
 
+	if err = r.recordTransaction(rows[0].Id, types.AccountTransactionTypeIncome, rows[0].Balance, "starting balance", ""); err != nil {
+		return nil, err
+	}
+
 	return rows[0], nil
 }
 
@@ -167,7 +227,24 @@ func (r *channelAccountsRepo) Create(
 func (r *channelAccountsRepo) Update(
 	channelAccount *types.ChannelAccount,
 ) (*types.ChannelAccount, error) {
-	var _, err = r.db.Exec(
+	// Retrieve the current balance so that any change can be recorded as an adjustment.
+	existing, _, err := r.List(
+		&types.ListChannelAccountsFilter{
+			Id: channelAccount.Id,
+		},
+		1,
+		"",
+		"",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(existing) == 0 {
+		return nil, fmt.Errorf("failed to retrieve the channel_account to update: %v", channelAccount.Id)
+	}
+
+	_, err = r.db.Exec(
 		getChannelAccountQueries()[channelAccountUpdate],
 		channelAccount.Balance,
 		channelAccount.EarnedToday,
@@ -176,6 +253,8 @@ func (r *channelAccountsRepo) Update(
 		channelAccount.SpentThisWeek,
 		channelAccount.EarnedThisYear,
 		channelAccount.SpentThisYear,
+		channelAccount.EarnedThisSeason,
+		channelAccount.SpentThisSeason,
 		channelAccount.EarnedAllTime,
 		channelAccount.SpentAllTime,
 		channelAccount.Id,
@@ -204,31 +283,53 @@ func (r *channelAccountsRepo) Update(
 		return nil, fmt.Errorf("failed to retrieve the updated channel_account: %v", channelAccount.Id)
 	}
 
+	if err = r.recordTransaction(channelAccount.Id, types.AccountTransactionTypeAdjustment, rows[0].Balance-existing[0].Balance, "admin adjustment", ""); err != nil {
+		return nil, err
+	}
+
 	return rows[0], nil
 }
 
-// Spend will update a channel account to reflect a new spend amount.
+// Spend will update a channel account to reflect a new spend amount. ErrInsufficientFunds is returned if the balance
+// isn't high enough (or the account doesn't exist).
 func (r *channelAccountsRepo) Spend(
 	id int,
 	amount int,
+	messageId string,
 ) error {
-	var _, err = r.db.Exec(
+	res, err := r.db.Exec(
 		getChannelAccountQueries()[channelAccountSpend],
 		amount,
 		amount,
 		amount,
 		amount,
 		amount,
+		amount,
 		id,
+		amount,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	// The balance is checked by the update itself so that concurrent spends can't take it below zero.
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrInsufficientFunds
+	}
+
+	return r.recordTransaction(id, types.AccountTransactionTypeSpend, -amount, "boosted bounty", messageId)
 }
 
 // Award will update a channel account to reflect a new award amount.
 func (r *channelAccountsRepo) Award(
 	id int,
 	amount int,
+	messageId string,
 ) error {
 	var _, err = r.db.Exec(
 		getChannelAccountQueries()[channelAccountAward],
@@ -237,8 +338,147 @@ func (r *channelAccountsRepo) Award(
 		amount,
 		amount,
 		amount,
+		amount,
 		id,
 	)
+	if err != nil {
+		return err
+	}
+
+	return r.recordTransaction(id, types.AccountTransactionTypeAward, amount, "awarded bounty", messageId)
+}
+
+// AwardBonus will add a bonus to a channel account. Bonuses are minted by the bot rather than paid from the bounty so
+// they're recorded separately in the ledger.
+func (r *channelAccountsRepo) AwardBonus(
+	id int,
+	amount int,
+	messageId string,
+) error {
+	var _, err = r.db.Exec(
+		getChannelAccountQueries()[channelAccountAward],
+		amount,
+		amount,
+		amount,
+		amount,
+		amount,
+		amount,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.recordTransaction(id, types.AccountTransactionTypeBonus, amount, "fast review bonus", messageId)
+}
+
+// Refund will return a previous spend to a channel account. Spend tracking is reduced so that refunded
+// boosts don't count towards the leaderboards.
+func (r *channelAccountsRepo) Refund(
+	id int,
+	amount int,
+	messageId string,
+) error {
+	var _, err = r.db.Exec(
+		getChannelAccountQueries()[channelAccountRefund],
+		amount,
+		amount,
+		amount,
+		amount,
+		amount,
+		amount,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.recordTransaction(id, types.AccountTransactionTypeRefund, amount, "refunded boost", messageId)
+}
+
+// Transfer moves points from one channel account to another, counting them as spent by the sender and earned by the
+// recipient.
+func (r *channelAccountsRepo) Transfer(
+	fromId int,
+	toId int,
+	amount int,
+) error {
+	res, err := r.db.Exec(
+		getChannelAccountQueries()[channelAccountSpend],
+		amount,
+		amount,
+		amount,
+		amount,
+		amount,
+		amount,
+		fromId,
+		amount,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrInsufficientFunds
+	}
+
+	if _, err = r.db.Exec(
+		getChannelAccountQueries()[channelAccountAward],
+		amount,
+		amount,
+		amount,
+		amount,
+		amount,
+		amount,
+		toId,
+	); err != nil {
+		return err
+	}
+
+	if err = r.recordTransaction(fromId, types.AccountTransactionTypeTip, -amount, "sent tip", ""); err != nil {
+		return err
+	}
+
+	return r.recordTransaction(toId, types.AccountTransactionTypeTip, amount, "received tip", "")
+}
+
+// recordTransaction adds the balance change to the ledger if it has been enabled.
+func (r *channelAccountsRepo) recordTransaction(id int, transactionType string, amount int, reason string, messageId string) error {
+	if r.transactionsRepo == nil || amount == 0 {
+		return nil
+	}
+
+	return r.transactionsRepo.Create(
+		&types.AccountTransaction{
+			ChannelAccountId: id,
+			Type:             transactionType,
+			Amount:           amount,
+			Reason:           reason,
+			MessageId:        messageId,
+		},
+	)
+}
+
+// SetFrozen will freeze or unfreeze a user's account in a channel, or in every channel if no channel is provided.
+func (r *channelAccountsRepo) SetFrozen(
+	teamId string,
+	userId string,
+	channelId string,
+	frozen bool,
+) error {
+	var _, err = r.db.Exec(
+		getChannelAccountQueries()[channelAccountSetFrozen],
+		frozen,
+		teamId,
+		userId,
+		channelId,
+		channelId,
+	)
 
 	return err
 }
@@ -261,60 +501,102 @@ func (r *channelAccountsRepo) ResetYearly() error {
 	return err
 }
 
+// ResetSeason will reset season tracking for all channel accounts.
+func (r *channelAccountsRepo) ResetSeason() error {
+	var _, err = r.db.Exec(getChannelAccountQueries()[channelAccountResetSeason])
+	return err
+}
+
+// ResetSeasonBalances will remove the percentage of every balance (rounded down) at the start of a season. Frozen
+// accounts are included so that returning users don't keep an advantage.
+func (r *channelAccountsRepo) ResetSeasonBalances(percentage int) error {
+	if percentage <= 0 {
+		return nil
+	}
+
+	// Record the transactions first as the balances they're taken from will change.
+	if r.transactionsRepo != nil {
+		if _, err := r.db.Exec(
+			getChannelAccountQueries()[channelAccountRecordSeasonBalanceReset],
+			types.AccountTransactionTypeAdjustment,
+			percentage,
+			"season reset",
+			percentage,
+		); err != nil {
+			return errors.Wrap(err, "failed to record season balance reset")
+		}
+	}
+
+	var _, err = r.db.Exec(getChannelAccountQueries()[channelAccountSeasonBalanceReset], percentage)
+	return err
+}
+
 // LeadersToday will count the number of leading accounts for today.
-func (r *channelAccountsRepo) LeadersToday(channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error) {
+func (r *channelAccountsRepo) LeadersToday(teamId string, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error) {
 	// Get the count of active users
-	activeCount, err := r.ActiveTodayCount(channelId)
+	activeCount, err := r.ActiveTodayCount(teamId, channelId)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.getLeaders(" earned_today DESC", activeCount, channelId, percentageToShow, maxToShow)
+	return r.getLeaders(" earned_today DESC", activeCount, teamId, channelId, percentageToShow, maxToShow)
 }
 
 // LeadersThisWeek will count the number of leading accounts for this week.
-func (r *channelAccountsRepo) LeadersThisWeek(channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error) {
+func (r *channelAccountsRepo) LeadersThisWeek(teamId string, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error) {
 	// Get the count of active users
-	activeCount, err := r.ActiveThisWeekCount(channelId)
+	activeCount, err := r.ActiveThisWeekCount(teamId, channelId)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.getLeaders(" earned_this_week DESC", activeCount, channelId, percentageToShow, maxToShow)
+	return r.getLeaders(" earned_this_week DESC", activeCount, teamId, channelId, percentageToShow, maxToShow)
 }
 
 // LeadersThisYear will count the number of leading accounts for this week.
-func (r *channelAccountsRepo) LeadersThisYear(channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error) {
+func (r *channelAccountsRepo) LeadersThisYear(teamId string, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error) {
 	// Get the count of active users
-	activeCount, err := r.ActiveThisYearCount(channelId)
+	activeCount, err := r.ActiveThisYearCount(teamId, channelId)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.getLeaders(" earned_this_year DESC", activeCount, channelId, percentageToShow, maxToShow)
+	return r.getLeaders(" earned_this_year DESC", activeCount, teamId, channelId, percentageToShow, maxToShow)
 }
 
 // LeadersAllTime will count the number of leading accounts for this week.
-func (r *channelAccountsRepo) LeadersAllTime(channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error) {
+func (r *channelAccountsRepo) LeadersAllTime(teamId string, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error) {
 	// Get the count of active users
-	activeCount, err := r.ActiveAllTimeCount(channelId)
+	activeCount, err := r.ActiveAllTimeCount(teamId, channelId)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.getLeaders(" earned_all_time DESC", activeCount, channelId, percentageToShow, maxToShow)
+	return r.getLeaders(" earned_all_time DESC", activeCount, teamId, channelId, percentageToShow, maxToShow)
+}
+
+// LeadersThisSeason will return the leading accounts for the current season.
+func (r *channelAccountsRepo) LeadersThisSeason(teamId string, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error) {
+	activeCount, err := r.ActiveThisSeasonCount(teamId, channelId)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.getLeaders(" earned_this_season DESC", activeCount, teamId, channelId, percentageToShow, maxToShow)
 }
 
 // getLeaders is used as a generic mechanism to faciliate retrieving leaders (the leaderboard service calls).
-func (r *channelAccountsRepo) getLeaders(orderStatement string, activeCount int, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error) {
+func (r *channelAccountsRepo) getLeaders(orderStatement string, activeCount int, teamId string, channelId string, percentageToShow int, maxToShow int) ([]*types.ChannelAccount, error) {
 	numberToShow := calculateNumberOfLeadersToShow(activeCount, percentageToShow, maxToShow)
 
 	// Retrieve the channel accounts we want to show.
 	channelAccounts, _, err := r.List(
 		&types.ListChannelAccountsFilter{
-			Id:        0,
-			UserId:    "",
-			ChannelId: channelId,
+			Id:            0,
+			TeamId:        teamId,
+			UserId:        "",
+			ChannelId:     channelId,
+			ExcludeFrozen: true,
 		},
 		numberToShow,
 		"",
@@ -325,53 +607,98 @@ func (r *channelAccountsRepo) getLeaders(orderStatement string, activeCount int,
 }
 
 // ActiveTodayCount will count the number of accounts in the channel that are active today.
-func (r *channelAccountsRepo) ActiveTodayCount(channelId string) (int, error) {
-	return r.count(channelAccountActiveTodayCount, channelId)
+func (r *channelAccountsRepo) ActiveTodayCount(teamId string, channelId string) (int, error) {
+	return r.count(channelAccountActiveTodayCount, teamId, channelId)
 }
 
 // ActiveThisWeekCount will count the number of accounts in the channel that have been active this week.
-func (r *channelAccountsRepo) ActiveThisWeekCount(channelId string) (int, error) {
-	return r.count(channelAccountActiveThisWeekCount, channelId)
+func (r *channelAccountsRepo) ActiveThisWeekCount(teamId string, channelId string) (int, error) {
+	return r.count(channelAccountActiveThisWeekCount, teamId, channelId)
 }
 
 // ActiveThisYearCount will count the number of accounts in the channel that have been active this year.
-func (r *channelAccountsRepo) ActiveThisYearCount(channelId string) (int, error) {
-	return r.count(channelAccountActiveThisYearCount, channelId)
+func (r *channelAccountsRepo) ActiveThisYearCount(teamId string, channelId string) (int, error) {
+	return r.count(channelAccountActiveThisYearCount, teamId, channelId)
 }
 
 // ActiveAllTimeCount will count the number of accounts in the channel that have been active all time.
-func (r *channelAccountsRepo) ActiveAllTimeCount(channelId string) (int, error) {
-	return r.count(channelAccountActiveAllTimeCount, channelId)
+func (r *channelAccountsRepo) ActiveAllTimeCount(teamId string, channelId string) (int, error) {
+	return r.count(channelAccountActiveAllTimeCount, teamId, channelId)
+}
+
+// ActiveThisSeasonCount will count the number of accounts in the channel that have been active this season.
+func (r *channelAccountsRepo) ActiveThisSeasonCount(teamId string, channelId string) (int, error) {
+	return r.count(channelAccountActiveThisSeasonCount, teamId, channelId)
 }
 
 // DistinctChannels will return a list of all distinct channels. Used for leaderboards etc.
-func (r *channelAccountsRepo) DistinctChannels() (channelIds []string, err error) {
+func (r *channelAccountsRepo) DistinctChannels() (channels []*types.TeamChannel, err error) {
 	rows, err := r.db.Query(getChannelAccountQueries()[channelAccountsDistinctChannels])
 	if err != nil {
 		return nil, err
 	}
 
 	for rows.Next() {
-		var channelId string
-		if err = rows.Scan(&channelId); err != nil {
+		var channel types.TeamChannel
+		if err = rows.Scan(&channel.TeamId, &channel.ChannelId); err != nil {
 			return nil, err
 		}
 
-		channelIds = append(channelIds, channelId)
+		channels = append(channels, &channel)
 	}
 
-	return channelIds, nil
+	return channels, nil
+}
+
+// MergeIntoWorkspaceWallets adds the balances and counters of each user's channel accounts to their workspace wallet,
+// creating the wallet if they don't have one yet. Contributions and ledger transactions are moved to the wallet before
+// the channel accounts are removed, and tips and achievements are moved to the workspace so that they count towards its
+// limits. Returns the number of channel accounts that were merged.
+func (r *channelAccountsRepo) MergeIntoWorkspaceWallets() (int64, error) {
+	queries := getChannelAccountQueries()
+
+	if _, err := r.db.Exec(queries[channelAccountsCreateWorkspaceWallets], types.WorkspaceChannelId, types.WorkspaceChannelId, types.WorkspaceChannelId); err != nil {
+		return 0, errors.Wrap(err, "failed to create workspace wallets")
+	}
+
+	if _, err := r.db.Exec(queries[channelAccountsMergeIntoWorkspaceWallets], types.WorkspaceChannelId, types.WorkspaceChannelId); err != nil {
+		return 0, errors.Wrap(err, "failed to merge channel accounts into workspace wallets")
+	}
+
+	if _, err := r.db.Exec(queries[channelAccountsMoveContributionsToWorkspaceWallets], types.WorkspaceChannelId, types.WorkspaceChannelId); err != nil {
+		return 0, errors.Wrap(err, "failed to move contributions to workspace wallets")
+	}
+
+	if _, err := r.db.Exec(queries[channelAccountsMoveTransactionsToWorkspaceWallets], types.WorkspaceChannelId, types.WorkspaceChannelId); err != nil {
+		return 0, errors.Wrap(err, "failed to move account transactions to workspace wallets")
+	}
+
+	if _, err := r.db.Exec(queries[channelAccountsMoveTipsToWorkspaceWallets], types.WorkspaceChannelId, types.WorkspaceChannelId); err != nil {
+		return 0, errors.Wrap(err, "failed to move tips to the workspace")
+	}
+
+	if _, err := r.db.Exec(queries[channelAccountsMoveAchievementsToWorkspaceWallets], types.WorkspaceChannelId, types.WorkspaceChannelId); err != nil {
+		return 0, errors.Wrap(err, "failed to move achievements to the workspace")
+	}
+
+	res, err := r.db.Exec(queries[channelAccountsDeleteMerged], types.WorkspaceChannelId)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to remove merged channel accounts")
+	}
+
+	return res.RowsAffected()
 }
 
 // Count will use the provided query name to count rows.
 func (r *channelAccountsRepo) count(
 	countQueryToUse string,
+	teamId string,
 	channelId string,
 ) (count int, err error) {
 	var query = getChannelAccountQueries()[countQueryToUse]
 
 	// Execute the query
-	if err = r.db.QueryRow(query, channelId).Scan(&count); err != nil {
+	if err = r.db.QueryRow(query, teamId, channelId).Scan(&count); err != nil {
 		return 0, err
 	}
 
@@ -400,6 +727,12 @@ func (r *channelAccountsRepo) applyFilter(
 		args = append(args, filter.Id)
 	}
 
+	// Filter by team id if provided
+	if filter.TeamId != "" {
+		clauses = append(clauses, "team_id = ?")
+		args = append(args, filter.TeamId)
+	}
+
 	// Filter by user id if provided
 	if filter.UserId != "" {
 		clauses = append(clauses, "user_id = ?")
@@ -412,6 +745,11 @@ func (r *channelAccountsRepo) applyFilter(
 		args = append(args, filter.ChannelId)
 	}
 
+	// Leave out frozen accounts if requested
+	if filter.ExcludeFrozen {
+		clauses = append(clauses, "frozen = 0")
+	}
+
 	if len(clauses) != 0 {
 		query += " WHERE " + strings.Join(clauses, " AND ")
 	}
@@ -452,6 +790,7 @@ func (r *channelAccountsRepo) scanChannelAccounts(rows *sql.Rows) ([]*types.Chan
 		// Populate the row
 		if err := rows.Scan(
 			&channelAccount.Id,
+			&channelAccount.TeamId,
 			&channelAccount.UserId,
 			&channelAccount.ChannelId,
 			&channelAccount.Balance,
@@ -461,8 +800,11 @@ func (r *channelAccountsRepo) scanChannelAccounts(rows *sql.Rows) ([]*types.Chan
 			&channelAccount.SpentThisWeek,
 			&channelAccount.EarnedThisYear,
 			&channelAccount.SpentThisYear,
+			&channelAccount.EarnedThisSeason,
+			&channelAccount.SpentThisSeason,
 			&channelAccount.EarnedAllTime,
 			&channelAccount.SpentAllTime,
+			&channelAccount.Frozen,
 			&created,
 			&updated,
 		); err != nil {
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type ChannelSettingsRepo interface {
+	// Init will initialise our channel settings repo.
+	Init() error
+
+	// Get will retrieve the settings for a channel, nil is returned if the channel uses the defaults.
+	Get(teamId string, channelId string) (*types.ChannelSettings, error)
+
+	// Upsert will create or replace the settings for a channel.
+	Upsert(settings *types.ChannelSettings) (*types.ChannelSettings, error)
+}
+
+type channelSettingsRepo struct {
+	db  querier
+	log *logrus.Logger
+}
+
+func NewChannelSettingsRepo(
+	db *sql.DB,
+	log *logrus.Logger,
+) ChannelSettingsRepo {
+	return &channelSettingsRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Init initialises the channel settings repo.
+func (r *channelSettingsRepo) Init() error {
+	return nil
+}
+
+// Get will retrieve the settings for a channel, nil is returned if the channel uses the defaults.
+func (r *channelSettingsRepo) Get(teamId string, channelId string) (*types.ChannelSettings, error) {
+	rows, err := r.db.Query(
+		getChannelSettingsQueries()[channelSettingsGet],
+		teamId,
+		channelId,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve channel settings")
+	}
+
+	settings, err := r.scanChannelSettings(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings) == 0 {
+		return nil, nil
+	}
+
+	return settings[0], nil
+}
+
+// Upsert will create or replace the settings for a channel.
+func (r *channelSettingsRepo) Upsert(settings *types.ChannelSettings) (*types.ChannelSettings, error) {
+	if _, err := r.db.Exec(
+		getChannelSettingsQueries()[channelSettingsUpsert],
+		settings.TeamId,
+		settings.ChannelId,
+		settings.DailyDecay,
+		settings.DailyIncome,
+		settings.BoostReactions,
+		settings.TaskCompletedByMeReaction,
+		settings.ReleaseBountyReaction,
+		settings.MinBounty,
+		settings.MaxBounty,
+		settings.MaxBoostPerUser,
+		settings.DailySpendCap,
+		settings.UpdatedBy,
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to save channel settings: %v", settings.ChannelId)
+	}
+
+	return r.Get(settings.TeamId, settings.ChannelId)
+}
+
+// scanChannelSettings populates a slice of channel settings from db rows.
+func (r *channelSettingsRepo) scanChannelSettings(rows *sql.Rows) ([]*types.ChannelSettings, error) {
+	defer rows.Close()
+
+	var res []*types.ChannelSettings
+
+	for rows.Next() {
+		var (
+			settings        types.ChannelSettings
+			dailyDecay      sql.NullInt64
+			dailyIncome     sql.NullInt64
+			minBounty       sql.NullInt64
+			maxBounty       sql.NullInt64
+			maxBoostPerUser sql.NullInt64
+			dailySpendCap   sql.NullInt64
+			created         time.Time
+			updated         time.Time
+		)
+
+		if err := rows.Scan(
+			&settings.TeamId,
+			&settings.ChannelId,
+			&dailyDecay,
+			&dailyIncome,
+			&settings.BoostReactions,
+			&settings.TaskCompletedByMeReaction,
+			&settings.ReleaseBountyReaction,
+			&minBounty,
+			&maxBounty,
+			&maxBoostPerUser,
+			&dailySpendCap,
+			&settings.UpdatedBy,
+			&created,
+			&updated,
+		); err != nil {
+			return nil, err
+		}
+
+		// Null values fall back to the config.
+		if dailyDecay.Valid {
+			value := int(dailyDecay.Int64)
+			settings.DailyDecay = &value
+		}
+
+		if dailyIncome.Valid {
+			value := int(dailyIncome.Int64)
+			settings.DailyIncome = &value
+		}
+
+		settings.MinBounty = nullableInt(minBounty)
+		settings.MaxBounty = nullableInt(maxBounty)
+		settings.MaxBoostPerUser = nullableInt(maxBoostPerUser)
+		settings.DailySpendCap = nullableInt(dailySpendCap)
+
+		// Assign timestamps
+		settings.Created = *timestamppb.New(created)
+		settings.Updated = *timestamppb.New(updated)
+
+		res = append(res, &settings)
+	}
+
+	return res, nil
+}
+
+// nullableInt returns nil for a null value so that the setting falls back to the config.
+func nullableInt(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+
+	result := int(value.Int64)
+	return &result
+}
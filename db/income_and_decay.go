@@ -0,0 +1,50 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/buzzology/slack_bot/types"
+)
+
+// incomeAndDecayExpressions returns the sql expressions for an account's balance once the decay has been applied and
+// once the income (and cap) has been applied. They expect channel_accounts to be aliased as ca and channel_settings as
+// cs. A channel's own daily decay replaces the policy in every mode. Decay never takes a balance below zero.
+func incomeAndDecayExpressions(decay *types.DecayPolicy, incomeToApply int) (string, string) {
+	var decayAmount string
+	switch decay.Mode {
+	case types.DecayModePercentage:
+		decayAmount = fmt.Sprintf("FLOOR(ca.balance * %d / 100)", decay.Percentage)
+	case types.DecayModeTiered:
+		// The highest tier the balance has reached applies.
+		tiers := make([]*types.DecayTier, len(decay.Tiers))
+		copy(tiers, decay.Tiers)
+		sort.Slice(tiers, func(i, j int) bool {
+			return tiers[i].MinBalance > tiers[j].MinBalance
+		})
+
+		var cases []string
+		for _, tier := range tiers {
+			cases = append(cases, fmt.Sprintf("WHEN ca.balance >= %d THEN FLOOR(ca.balance * %d / 100)", tier.MinBalance, tier.Percentage))
+		}
+
+		decayAmount = "0"
+		if len(cases) > 0 {
+			decayAmount = "(CASE " + strings.Join(cases, " ") + " ELSE 0 END)"
+		}
+	default:
+		decayAmount = fmt.Sprintf("%d", decay.Amount)
+	}
+
+	decayAmount = fmt.Sprintf("COALESCE(cs.daily_decay, %s)", decayAmount)
+
+	afterDecay := fmt.Sprintf("GREATEST(ca.balance - %s, 0)", decayAmount)
+	afterIncome := fmt.Sprintf("(%s + COALESCE(cs.daily_income, %d))", afterDecay, incomeToApply)
+
+	if decay.MaxBalance > 0 {
+		afterIncome = fmt.Sprintf("LEAST(%s, %d)", afterIncome, decay.MaxBalance)
+	}
+
+	return afterDecay, afterIncome
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/buzzology/slack_bot/types"
+)
+
+func TestIncomeAndDecayExpressions(t *testing.T) {
+	tests := []struct {
+		name            string
+		decay           *types.DecayPolicy
+		incomeToApply   int
+		wantAfterDecay  string
+		wantAfterIncome string
+	}{
+		{
+			name:            "flat",
+			decay:           &types.DecayPolicy{Mode: types.DecayModeFlat, Amount: 2},
+			incomeToApply:   1,
+			wantAfterDecay:  "GREATEST(ca.balance - COALESCE(cs.daily_decay, 2), 0)",
+			wantAfterIncome: "(GREATEST(ca.balance - COALESCE(cs.daily_decay, 2), 0) + COALESCE(cs.daily_income, 1))",
+		},
+		{
+			name:            "unknown mode is flat",
+			decay:           &types.DecayPolicy{Mode: "", Amount: 3},
+			incomeToApply:   0,
+			wantAfterDecay:  "GREATEST(ca.balance - COALESCE(cs.daily_decay, 3), 0)",
+			wantAfterIncome: "(GREATEST(ca.balance - COALESCE(cs.daily_decay, 3), 0) + COALESCE(cs.daily_income, 0))",
+		},
+		{
+			name:            "percentage",
+			decay:           &types.DecayPolicy{Mode: types.DecayModePercentage, Amount: 2, Percentage: 10},
+			incomeToApply:   1,
+			wantAfterDecay:  "GREATEST(ca.balance - COALESCE(cs.daily_decay, FLOOR(ca.balance * 10 / 100)), 0)",
+			wantAfterIncome: "(GREATEST(ca.balance - COALESCE(cs.daily_decay, FLOOR(ca.balance * 10 / 100)), 0) + COALESCE(cs.daily_income, 1))",
+		},
+		{
+			name: "tiered uses the highest tier reached",
+			decay: &types.DecayPolicy{
+				Mode: types.DecayModeTiered,
+				Tiers: []*types.DecayTier{
+					{MinBalance: 100, Percentage: 5},
+					{MinBalance: 500, Percentage: 10},
+				},
+			},
+			incomeToApply:   1,
+			wantAfterDecay:  "GREATEST(ca.balance - COALESCE(cs.daily_decay, (CASE WHEN ca.balance >= 500 THEN FLOOR(ca.balance * 10 / 100) WHEN ca.balance >= 100 THEN FLOOR(ca.balance * 5 / 100) ELSE 0 END)), 0)",
+			wantAfterIncome: "(GREATEST(ca.balance - COALESCE(cs.daily_decay, (CASE WHEN ca.balance >= 500 THEN FLOOR(ca.balance * 10 / 100) WHEN ca.balance >= 100 THEN FLOOR(ca.balance * 5 / 100) ELSE 0 END)), 0) + COALESCE(cs.daily_income, 1))",
+		},
+		{
+			name:            "tiered without tiers doesn't decay",
+			decay:           &types.DecayPolicy{Mode: types.DecayModeTiered},
+			incomeToApply:   1,
+			wantAfterDecay:  "GREATEST(ca.balance - COALESCE(cs.daily_decay, 0), 0)",
+			wantAfterIncome: "(GREATEST(ca.balance - COALESCE(cs.daily_decay, 0), 0) + COALESCE(cs.daily_income, 1))",
+		},
+		{
+			name:            "max balance",
+			decay:           &types.DecayPolicy{Mode: types.DecayModeFlat, Amount: 2, MaxBalance: 50},
+			incomeToApply:   1,
+			wantAfterDecay:  "GREATEST(ca.balance - COALESCE(cs.daily_decay, 2), 0)",
+			wantAfterIncome: "LEAST((GREATEST(ca.balance - COALESCE(cs.daily_decay, 2), 0) + COALESCE(cs.daily_income, 1)), 50)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			afterDecay, afterIncome := incomeAndDecayExpressions(tt.decay, tt.incomeToApply)
+			if afterDecay != tt.wantAfterDecay {
+				t.Errorf("after decay = %v, want %v", afterDecay, tt.wantAfterDecay)
+			}
+
+			if afterIncome != tt.wantAfterIncome {
+				t.Errorf("after income = %v, want %v", afterIncome, tt.wantAfterIncome)
+			}
+		})
+	}
+}
+
+func TestIncomeAndDecayExpressionsLeavesTiersUnsorted(t *testing.T) {
+	tiers := []*types.DecayTier{
+		{MinBalance: 100, Percentage: 5},
+		{MinBalance: 500, Percentage: 10},
+	}
+
+	incomeAndDecayExpressions(&types.DecayPolicy{Mode: types.DecayModeTiered, Tiers: tiers}, 1)
+
+	if tiers[0].MinBalance != 100 || tiers[1].MinBalance != 500 {
+		t.Errorf("tiers were reordered: %v, %v", tiers[0].MinBalance, tiers[1].MinBalance)
+	}
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type InstallationsRepo interface {
+	// Init will initialise our installations repo.
+	Init() error
+
+	// Get will retrieve the installation for a workspace, nil is returned if it hasn't been installed.
+	Get(teamId string) (*types.Installation, error)
+
+	// Upsert will create or replace the installation for a workspace.
+	Upsert(installation *types.Installation) (*types.Installation, error)
+
+	// WithTx will return a copy of the repo that runs its queries in the transaction.
+	WithTx(tx *sql.Tx) InstallationsRepo
+
+	// LegacyRowCount will count the accounts and bounties created before multiple workspaces were supported.
+	LegacyRowCount() (int, error)
+
+	// AdoptLegacyRows will assign the rows created before multiple workspaces were supported to the workspace. It
+	// should be run in a transaction so that a workspace's rows aren't left partially assigned.
+	AdoptLegacyRows(teamId string) (int64, error)
+}
+
+// legacyTeamTables are the tables that can have rows from before multiple workspaces were supported, either created
+// before team ids were added or copied from those rows (e.g. opening balances in the ledger). Channel settings are
+// left out as they've always had a team id.
+var legacyTeamTables = []string{
+	"channel_accounts",
+	"message_bounties",
+	"bot_messages",
+	"message_bounty_contributions",
+	"account_transactions",
+	"bounty_claims",
+	"tips",
+	"achievements",
+	"season_results",
+}
+
+type installationsRepo struct {
+	db  querier
+	log *logrus.Logger
+}
+
+func NewInstallationsRepo(
+	db *sql.DB,
+	log *logrus.Logger,
+) InstallationsRepo {
+	return &installationsRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Init initialises the installations repo.
+func (r *installationsRepo) Init() error {
+	return nil
+}
+
+// Get will retrieve the installation for a workspace, nil is returned if it hasn't been installed.
+func (r *installationsRepo) Get(teamId string) (*types.Installation, error) {
+	rows, err := r.db.Query(
+		getInstallationQueries()[installationGet],
+		teamId,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve installation")
+	}
+
+	installations, err := r.scanInstallations(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(installations) == 0 {
+		return nil, nil
+	}
+
+	return installations[0], nil
+}
+
+// Upsert will create or replace the installation for a workspace.
+func (r *installationsRepo) Upsert(installation *types.Installation) (*types.Installation, error) {
+	if _, err := r.db.Exec(
+		getInstallationQueries()[installationUpsert],
+		installation.TeamId,
+		installation.TeamName,
+		installation.AppId,
+		installation.BotUserId,
+		installation.BotToken,
+		installation.Scope,
+		installation.InstalledBy,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to save installation")
+	}
+
+	return r.Get(installation.TeamId)
+}
+
+// WithTx returns a copy of the repo that runs its queries in the transaction.
+func (r *installationsRepo) WithTx(tx *sql.Tx) InstallationsRepo {
+	return &installationsRepo{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+// LegacyRowCount will count the accounts and bounties created before multiple workspaces were supported.
+func (r *installationsRepo) LegacyRowCount() (int, error) {
+	var count int
+	if err := r.db.QueryRow(getInstallationQueries()[installationsCountLegacyRows]).Scan(&count); err != nil {
+		return 0, errors.Wrap(err, "failed to count legacy rows")
+	}
+
+	return count, nil
+}
+
+// AdoptLegacyRows will assign the rows without a team id to the workspace. Returns the number of rows updated.
+func (r *installationsRepo) AdoptLegacyRows(teamId string) (int64, error) {
+	if teamId == "" {
+		return 0, errors.New("a team id is required to adopt legacy rows")
+	}
+
+	var adopted int64
+	for _, table := range legacyTeamTables {
+		res, err := r.db.Exec(fmt.Sprintf(getInstallationQueries()[installationsAdoptLegacyRows], table), teamId)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to adopt legacy rows in %v", table)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+
+		adopted += affected
+	}
+
+	return adopted, nil
+}
+
+// scanInstallations populates a slice of installations from db rows.
+func (r *installationsRepo) scanInstallations(rows *sql.Rows) ([]*types.Installation, error) {
+	defer rows.Close()
+
+	var res []*types.Installation
+
+	for rows.Next() {
+		var (
+			installation types.Installation
+			created      time.Time
+			updated      time.Time
+		)
+
+		if err := rows.Scan(
+			&installation.TeamId,
+			&installation.TeamName,
+			&installation.AppId,
+			&installation.BotUserId,
+			&installation.BotToken,
+			&installation.Scope,
+			&installation.InstalledBy,
+			&created,
+			&updated,
+		); err != nil {
+			return nil, err
+		}
+
+		// Assign timestamps
+		installation.Created = *timestamppb.New(created)
+		installation.Updated = *timestamppb.New(updated)
+
+		res = append(res, &installation)
+	}
+
+	return res, nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -12,25 +13,43 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// ErrBountyNotOpen is returned when a bounty is no longer open, or has changed since it was read.
+var ErrBountyNotOpen = errors.New("bounty is no longer open")
+
 type MessageBountiesRepo interface {
 	// Init will initialise our message bounties repo.
 	Init() error
 
+	// WithTx will return a copy of the repo that runs its queries in the transaction.
+	WithTx(tx *sql.Tx) MessageBountiesRepo
+
 	// List will return a collection of message bounties.
 	List(filter *types.ListMessageBountiesFilter, pageSize int, pageToken string) ([]*types.MessageBounty, string, error)
 
 	// Create will create a new message bounty.
 	Create(messageBounty *types.MessageBounty) (*types.MessageBounty, error)
 
-	// Update will update an existing message bounty.
+	// Update will update an existing message bounty. The amount and status are left as is, they're only changed by
+	// BoostBounty and Close so that a stale copy of the bounty can't revert them.
 	Update(messageBounty *types.MessageBounty) (*types.MessageBounty, error)
 
-	// BoostBounty will boost an existing bounty.
-	BoostBounty(messageId string, boostAmount int) error
+	// Close will move an open bounty to the provided status. ErrBountyNotOpen is returned if the bounty has already been
+	// closed or its amount has changed since it was read.
+	Close(messageBounty *types.MessageBounty, status int, awardedTo string) error
+
+	// HandOver will make another user the owner of an open bounty. ErrBountyNotOpen is returned if the bounty has been
+	// closed or handed over since it was read.
+	HandOver(messageBounty *types.MessageBounty, newOwner string) error
+
+	// BoostBounty will boost an existing bounty. ErrBountyNotOpen is returned if the bounty is no longer open.
+	BoostBounty(teamId string, channelId string, messageId string, boostAmount int) error
+
+	// DistinctChannels will return a list of all distinct channels that have had a bounty.
+	DistinctChannels() ([]*types.TeamChannel, error)
 }
 
 type messageBountiesRepo struct {
-	db  *sql.DB
+	db  querier
 	log *logrus.Logger
 }
 
@@ -49,6 +68,14 @@ func (r *messageBountiesRepo) Init() error {
 	return nil
 }
 
+// WithTx returns a copy of the repo that runs its queries in the transaction.
+func (r *messageBountiesRepo) WithTx(tx *sql.Tx) MessageBountiesRepo {
+	return &messageBountiesRepo{
+		db:  tx,
+		log: r.log,
+	}
+}
+
 // List will retrieve and list message bounties matching the provided criteria.
 func (r *messageBountiesRepo) List(
 	filter *types.ListMessageBountiesFilter,
@@ -58,6 +85,11 @@ func (r *messageBountiesRepo) List(
 	var args []interface{}
 	var query = getMessageBountyQueries()[messageBountiesList]
 
+	// Validate page size
+	if pageSize > 100 || pageSize <= 0 {
+		pageSize = 100
+	}
+
 	// Prepare query
 	query, args = r.applyFilter(query, filter, pageSize, pageToken)
 
@@ -74,13 +106,14 @@ func (r *messageBountiesRepo) List(
 		return nil, "", err
 	}
 
-	// No results
-	if len(messageBounties) == 0 {
+	// No more results
+	if len(messageBounties) == 0 || len(messageBounties) < pageSize {
 		return messageBounties, "", nil
 	}
 
-	// Return the results along with the id of the last account as a next page token
-	return messageBounties, fmt.Sprint(messageBounties[len(messageBounties)-1].MessageId), nil
+	// Return the results along with the offset of the next page as a next page token
+	offset, _ := strconv.Atoi(pageToken)
+	return messageBounties, fmt.Sprint(offset + len(messageBounties)), nil
 }
 
 // Create will create a new message bounty.
@@ -90,6 +123,7 @@ func (r *messageBountiesRepo) Create(
 	var _, err = r.db.Exec(
 		getMessageBountyQueries()[messageBountyCreate],
 		messageBounty.MessageId,
+		messageBounty.TeamId,
 		messageBounty.UserId,
 		messageBounty.ChannelId,
 		messageBounty.CurrentBounty,
@@ -103,6 +137,8 @@ func (r *messageBountiesRepo) Create(
 	// Retrieve the row
 	rows, _, err := r.List(
 		&types.ListMessageBountiesFilter{
+			TeamId:    messageBounty.TeamId,
+			ChannelId: messageBounty.ChannelId,
 			MessageId: messageBounty.MessageId,
 		},
 		1,
@@ -123,30 +159,133 @@ func (r *messageBountiesRepo) Create(
 
 // BoostBounty will boost the amount currently being offered for a bounty.
 func (r *messageBountiesRepo) BoostBounty(
+	teamId string,
+	channelId string,
 	messageId string,
 	amount int,
 ) error {
-	var _, err = r.db.Exec(
+	res, err := r.db.Exec(
 		getMessageBountyQueries()[messageBountyBoost],
 		amount,
+		teamId,
+		channelId,
 		messageId,
+		types.MessageBountyStatusOpen,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrBountyNotOpen
+	}
+
+	return nil
+}
+
+// Close will move an open bounty to the provided status. The status and amount are checked by the update itself so
+// that concurrent awards, cancellations and boosts can't each act on the same open bounty.
+func (r *messageBountiesRepo) Close(
+	messageBounty *types.MessageBounty,
+	status int,
+	awardedTo string,
+) error {
+	res, err := r.db.Exec(
+		getMessageBountyQueries()[messageBountyClose],
+		status,
+		awardedTo,
+		messageBounty.TeamId,
+		messageBounty.ChannelId,
+		messageBounty.MessageId,
+		types.MessageBountyStatusOpen,
+		messageBounty.CurrentBounty,
 	)
 	if err != nil {
 		return err
 	}
 
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected != 1 {
+		return ErrBountyNotOpen
+	}
+
 	return nil
 }
 
+// HandOver will make another user the owner of an open bounty. The status and current owner are checked by the update
+// itself so that a bounty awarded or cancelled at the same time isn't changed.
+func (r *messageBountiesRepo) HandOver(messageBounty *types.MessageBounty, newOwner string) error {
+	res, err := r.db.Exec(
+		getMessageBountyQueries()[messageBountyHandOver],
+		newOwner,
+		messageBounty.TeamId,
+		messageBounty.ChannelId,
+		messageBounty.MessageId,
+		messageBounty.UserId,
+		types.MessageBountyStatusOpen,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected != 1 {
+		return ErrBountyNotOpen
+	}
+
+	return nil
+}
+
+// DistinctChannels will return a list of all distinct channels that have had a bounty. Used for leaderboards when
+// accounts aren't tied to a channel.
+func (r *messageBountiesRepo) DistinctChannels() (channels []*types.TeamChannel, err error) {
+	rows, err := r.db.Query(getMessageBountyQueries()[messageBountiesDistinctChannels])
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var channel types.TeamChannel
+		if err = rows.Scan(&channel.TeamId, &channel.ChannelId); err != nil {
+			return nil, err
+		}
+
+		channels = append(channels, &channel)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+}
+
 // Update will modify an existing message bounty.
 func (r *messageBountiesRepo) Update(
 	messageBounty *types.MessageBounty,
 ) (*types.MessageBounty, error) {
 	var _, err = r.db.Exec(
 		getMessageBountyQueries()[messageBountyUpdate],
-		messageBounty.CurrentBounty,
-		messageBounty.Status,
+		messageBounty.UserId,
 		messageBounty.AwardedTo,
+		messageBounty.ExpiryWarned,
+		messageBounty.TeamId,
+		messageBounty.ChannelId,
 		messageBounty.MessageId,
 	)
 	if err != nil {
@@ -158,6 +297,8 @@ func (r *messageBountiesRepo) Update(
 	// Retrieve the row
 	rows, _, err := r.List(
 		&types.ListMessageBountiesFilter{
+			TeamId:    messageBounty.TeamId,
+			ChannelId: messageBounty.ChannelId,
 			MessageId: messageBounty.MessageId,
 		},
 		1,
@@ -197,6 +338,12 @@ func (r *messageBountiesRepo) applyFilter(
 		args = append(args, filter.MessageId)
 	}
 
+	// Filter by team id if provided
+	if filter.TeamId != "" {
+		clauses = append(clauses, "team_id = ?")
+		args = append(args, filter.TeamId)
+	}
+
 	// Filter by user id if provided
 	if filter.UserId != "" {
 		clauses = append(clauses, "user_id = ?")
@@ -209,6 +356,23 @@ func (r *messageBountiesRepo) applyFilter(
 		args = append(args, filter.ChannelId)
 	}
 
+	// Filter by status if provided
+	if filter.Status > 0 {
+		clauses = append(clauses, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	// Filter by created if provided
+	if !filter.CreatedBefore.IsZero() {
+		clauses = append(clauses, "created < ?")
+		args = append(args, filter.CreatedBefore)
+	}
+
+	// Exclude bounties that have already had an expiry warning if requested
+	if filter.ExcludeExpiryWarned {
+		clauses = append(clauses, "expiry_warned = 0")
+	}
+
 	if len(clauses) != 0 {
 		query += " WHERE " + strings.Join(clauses, " AND ")
 	}
@@ -218,6 +382,9 @@ func (r *messageBountiesRepo) applyFilter(
 		pageSize = 100
 	}
 
+	// Oldest bounties first so that pages are stable and long running jobs get to the ones that have waited longest.
+	query += " ORDER BY created ASC, message_id ASC"
+
 	// Limit page size
 	// NOTE: We will likely want to keep the limit and remove the offset. Instead we should dynamically filter using
 	//       a where clause based on the sort order. E.g. if sorting by id `where id > page_token ORDER BY id`
@@ -225,7 +392,9 @@ func (r *messageBountiesRepo) applyFilter(
 	if err == nil {
 		query += fmt.Sprintf(" LIMIT %v, %v", pageTokenI, pageSize)
 	} else {
-		r.log.Warningf("invalid page token provided: %v", pageToken)
+		if pageToken != "" {
+			r.log.Warningf("invalid page token provided: %v", pageToken)
+		}
 		query += fmt.Sprintf(" LIMIT 0, %v", pageSize)
 	}
 
@@ -248,11 +417,13 @@ func (r *messageBountiesRepo) scanMessageBounties(rows *sql.Rows) ([]*types.Mess
 		// Populate the row
 		if err := rows.Scan(
 			&messageBounty.MessageId,
+			&messageBounty.TeamId,
 			&messageBounty.UserId,
 			&messageBounty.ChannelId,
 			&messageBounty.CurrentBounty,
 			&messageBounty.Status,
 			&messageBounty.AwardedTo,
+			&messageBounty.ExpiryWarned,
 			&created,
 			&updated,
 		); err != nil {
@@ -0,0 +1,337 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrContributionNotActive is returned when a contribution has already been refunded, or doesn't exist.
+var ErrContributionNotActive = errors.New("contribution is no longer active")
+
+type MessageBountyContributionsRepo interface {
+	// Init will initialise our message bounty contributions repo.
+	Init() error
+
+	// WithTx will return a copy of the repo that runs its queries in the transaction.
+	WithTx(tx *sql.Tx) MessageBountyContributionsRepo
+
+	// List will return a collection of message bounty contributions.
+	List(filter *types.ListMessageBountyContributionsFilter, pageSize int, pageToken string) ([]*types.MessageBountyContribution, string, error)
+
+	// ListActive will return every active contribution to a bounty, most recent first.
+	ListActive(teamId string, messageId string, channelId string) ([]*types.MessageBountyContribution, error)
+
+	// Create will record a new contribution to a bounty.
+	Create(contribution *types.MessageBountyContribution) error
+
+	// UpdateStatus will update the status of an active contribution. ErrContributionNotActive is returned if it's no
+	// longer active so that it can't be refunded twice.
+	UpdateStatus(id int, status int) error
+
+	// Contributors will return the total each user has put towards a bounty, largest first.
+	Contributors(teamId string, messageId string, channelId string) ([]*types.BountyContributor, error)
+
+	// SumSince will return the total the user has put towards bounties in the channel since the provided time, refunded
+	// contributions aren't included.
+	SumSince(teamId string, channelId string, userId string, since time.Time) (int, error)
+}
+
+type messageBountyContributionsRepo struct {
+	db  querier
+	log *logrus.Logger
+}
+
+func NewMessageBountyContributionsRepo(
+	db *sql.DB,
+	log *logrus.Logger,
+) MessageBountyContributionsRepo {
+	return &messageBountyContributionsRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Init initialises the message bounty contributions repo.
+func (r *messageBountyContributionsRepo) Init() error {
+	return nil
+}
+
+// WithTx returns a copy of the repo that runs its queries in the transaction.
+func (r *messageBountyContributionsRepo) WithTx(tx *sql.Tx) MessageBountyContributionsRepo {
+	return &messageBountyContributionsRepo{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+// List will retrieve and list contributions matching the provided criteria.
+func (r *messageBountyContributionsRepo) List(
+	filter *types.ListMessageBountyContributionsFilter,
+	pageSize int,
+	pageToken string,
+) ([]*types.MessageBountyContribution, string, error) {
+	var args []interface{}
+	var query = getMessageBountyContributionQueries()[messageBountyContributionsList]
+
+	// Prepare query
+	query, args = r.applyFilter(query, filter, pageSize, pageToken)
+
+	// Execute the query
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Parse rows
+	contributions, err := r.scanContributions(rows)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// No results
+	if len(contributions) == 0 {
+		return contributions, "", nil
+	}
+
+	return contributions, fmt.Sprint(contributions[len(contributions)-1].Id), nil
+}
+
+// ListActive will return every active contribution to a bounty, most recent first. It isn't limited to a page so that
+// none are missed when the bounty is refunded or handed over.
+func (r *messageBountyContributionsRepo) ListActive(
+	teamId string,
+	messageId string,
+	channelId string,
+) ([]*types.MessageBountyContribution, error) {
+	rows, err := r.db.Query(
+		getMessageBountyContributionQueries()[messageBountyContributionsListActive],
+		teamId,
+		messageId,
+		channelId,
+		types.MessageBountyContributionStatusActive,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list active message bounty contributions")
+	}
+
+	return r.scanContributions(rows)
+}
+
+// Create will record a new contribution to a bounty.
+func (r *messageBountyContributionsRepo) Create(contribution *types.MessageBountyContribution) error {
+	if _, err := r.db.Exec(
+		getMessageBountyContributionQueries()[messageBountyContributionCreate],
+		contribution.TeamId,
+		contribution.MessageId,
+		contribution.ChannelId,
+		contribution.UserId,
+		contribution.ChannelAccountId,
+		contribution.Reaction,
+		contribution.Amount,
+		contribution.Status,
+	); err != nil {
+		return errors.Wrap(err, "failed to create message bounty contribution")
+	}
+
+	return nil
+}
+
+// UpdateStatus will update the status of an active contribution. ErrContributionNotActive is returned if it's no
+// longer active so that it can't be refunded twice.
+func (r *messageBountyContributionsRepo) UpdateStatus(id int, status int) error {
+	res, err := r.db.Exec(
+		getMessageBountyContributionQueries()[messageBountyContributionUpdateStatus],
+		status,
+		id,
+		types.MessageBountyContributionStatusActive,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update message bounty contribution status")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrContributionNotActive
+	}
+
+	return nil
+}
+
+// Contributors will return the total of each user's active contributions to a bounty, largest first. Users that
+// contributed the same amount are ordered by who boosted the bounty first.
+func (r *messageBountyContributionsRepo) Contributors(
+	teamId string,
+	messageId string,
+	channelId string,
+) ([]*types.BountyContributor, error) {
+	rows, err := r.db.Query(
+		getMessageBountyContributionQueries()[messageBountyContributionsContributors],
+		teamId,
+		messageId,
+		channelId,
+		types.MessageBountyContributionStatusActive,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list message bounty contributors")
+	}
+
+	defer rows.Close()
+
+	var contributors []*types.BountyContributor
+	for rows.Next() {
+		var contributor types.BountyContributor
+		if err = rows.Scan(&contributor.UserId, &contributor.Amount); err != nil {
+			return nil, err
+		}
+
+		contributors = append(contributors, &contributor)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to list message bounty contributors")
+	}
+
+	return contributors, nil
+}
+
+// SumSince will return the total the user has put towards bounties in the channel since the provided time, refunded
+// contributions aren't included.
+func (r *messageBountyContributionsRepo) SumSince(teamId string, channelId string, userId string, since time.Time) (int, error) {
+	var total int
+	if err := r.db.QueryRow(
+		getMessageBountyContributionQueries()[messageBountyContributionsSumSince],
+		teamId,
+		channelId,
+		userId,
+		types.MessageBountyContributionStatusActive,
+		since,
+	).Scan(&total); err != nil {
+		return 0, errors.Wrap(err, "failed to total message bounty contributions")
+	}
+
+	return total, nil
+}
+
+func (r *messageBountyContributionsRepo) applyFilter(
+	query string,
+	filter *types.ListMessageBountyContributionsFilter,
+	pageSize int,
+	pageToken string,
+) (string, []interface{}) {
+	var (
+		clauses []string
+		args    []interface{}
+	)
+
+	if filter == nil {
+		return query, args
+	}
+
+	// Filter by team id if provided
+	if filter.TeamId != "" {
+		clauses = append(clauses, "team_id = ?")
+		args = append(args, filter.TeamId)
+	}
+
+	// Filter by message id if provided
+	if filter.MessageId != "" {
+		clauses = append(clauses, "message_id = ?")
+		args = append(args, filter.MessageId)
+	}
+
+	// Filter by channel id if provided
+	if filter.ChannelId != "" {
+		clauses = append(clauses, "channel_id = ?")
+		args = append(args, filter.ChannelId)
+	}
+
+	// Filter by user id if provided
+	if filter.UserId != "" {
+		clauses = append(clauses, "user_id = ?")
+		args = append(args, filter.UserId)
+	}
+
+	// Filter by reaction if provided
+	if filter.Reaction != "" {
+		clauses = append(clauses, "reaction = ?")
+		args = append(args, filter.Reaction)
+	}
+
+	// Filter by status if provided
+	if filter.Status > 0 {
+		clauses = append(clauses, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	if len(clauses) != 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	// Validate page size
+	if pageSize > 100 || pageSize <= 0 {
+		pageSize = 100
+	}
+
+	// Most recent contributions first so that the latest boost is reversed before earlier ones.
+	query += " ORDER BY id DESC"
+
+	pageTokenI, err := strconv.Atoi(pageToken)
+	if err == nil {
+		query += fmt.Sprintf(" LIMIT %v, %v", pageTokenI, pageSize)
+	} else {
+		query += fmt.Sprintf(" LIMIT 0, %v", pageSize)
+	}
+
+	return query, args
+}
+
+// scanContributions populates a slice of structs from db rows
+func (r *messageBountyContributionsRepo) scanContributions(rows *sql.Rows) ([]*types.MessageBountyContribution, error) {
+	defer rows.Close()
+
+	var res []*types.MessageBountyContribution
+
+	for rows.Next() {
+		var (
+			contribution types.MessageBountyContribution
+			created      time.Time
+			updated      time.Time
+		)
+
+		if err := rows.Scan(
+			&contribution.Id,
+			&contribution.TeamId,
+			&contribution.MessageId,
+			&contribution.ChannelId,
+			&contribution.UserId,
+			&contribution.ChannelAccountId,
+			&contribution.Reaction,
+			&contribution.Amount,
+			&contribution.Status,
+			&created,
+			&updated,
+		); err != nil {
+			return nil, err
+		}
+
+		// Assign timestamps
+		contribution.Created = *timestamppb.New(created)
+		contribution.Updated = *timestamppb.New(updated)
+
+		res = append(res, &contribution)
+	}
+
+	return res, nil
+}
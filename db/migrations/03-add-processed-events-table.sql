@@ -0,0 +1,10 @@
+CREATE TABLE `processed_events` (
+  `event_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `event_type` varchar(64) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `retries` int(11) NOT NULL DEFAULT 0,
+  `created` datetime NOT NULL,
+  `updated` datetime NOT NULL,
+  PRIMARY KEY (`event_id`),
+  KEY `processed_events_created` (`created`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
@@ -0,0 +1,25 @@
+CREATE TABLE `installations` (
+  `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `team_name` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `app_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `bot_user_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `bot_token` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `scope` varchar(1024) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `installed_by` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `created` datetime NOT NULL,
+  `updated` datetime NOT NULL,
+  PRIMARY KEY (`team_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Existing rows belong to the original (single) workspace and are left with an empty team_id. They're assigned to the
+-- LegacyTeamId from the config when the bot starts.
+ALTER TABLE `channel_accounts`
+  ADD COLUMN `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '' AFTER `id`,
+  ADD KEY `channel_accounts_team_channel_user` (`team_id`, `channel_id`, `user_id`);
+
+ALTER TABLE `message_bounties`
+  ADD COLUMN `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '' AFTER `message_id`,
+  ADD KEY `message_bounties_team_channel` (`team_id`, `channel_id`);
+
+ALTER TABLE `bot_messages`
+  ADD COLUMN `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '' AFTER `id`;
@@ -0,0 +1,15 @@
+CREATE TABLE `message_bounty_contributions` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `message_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `channel_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `user_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `channel_account_id` int(11) unsigned NOT NULL,
+  `reaction` varchar(64) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `amount` int(11) NOT NULL,
+  `status` int(11) NOT NULL,
+  `created` datetime NOT NULL,
+  `updated` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `message_bounty_contributions_message` (`message_id`, `channel_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
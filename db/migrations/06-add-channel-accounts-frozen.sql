@@ -0,0 +1,4 @@
+-- Accounts are frozen when the user leaves the channel or is deactivated. Frozen accounts don't receive income or decay
+-- and are left off the leaderboards.
+ALTER TABLE `channel_accounts`
+  ADD COLUMN `frozen` tinyint(1) NOT NULL DEFAULT 0 AFTER `spent_all_time`;
@@ -0,0 +1,14 @@
+-- Only populated when TransactionLedgerEnabled is set, rows are removed after TransactionRetentionDays.
+CREATE TABLE `account_transactions` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `channel_account_id` int(11) unsigned NOT NULL,
+  `type` varchar(16) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `amount` int(11) NOT NULL,
+  `reason` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `message_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `created` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `account_transactions_account` (`channel_account_id`, `created`),
+  KEY `account_transactions_created` (`created`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
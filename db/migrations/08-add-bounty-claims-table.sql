@@ -0,0 +1,13 @@
+CREATE TABLE `bounty_claims` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `message_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `channel_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `user_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `status` int(11) NOT NULL,
+  `amount` int(11) NOT NULL DEFAULT 0,
+  `created` datetime NOT NULL,
+  `updated` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `bounty_claims_message_user` (`message_id`, `channel_id`, `user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
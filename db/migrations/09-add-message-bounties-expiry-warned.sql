@@ -0,0 +1,4 @@
+-- Open bounties expire after a while, the owner is warned beforehand. This records that the warning has been sent so
+-- that it's only sent once.
+ALTER TABLE `message_bounties`
+  ADD COLUMN `expiry_warned` tinyint(1) NOT NULL DEFAULT 0 AFTER `awarded_to`;
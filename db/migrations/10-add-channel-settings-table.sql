@@ -0,0 +1,15 @@
+-- Channel settings override the economy settings in the TOML config for a single channel. A NULL (or empty) value
+-- means the channel uses the config's value.
+CREATE TABLE `channel_settings` (
+  `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `channel_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `daily_decay` int(11) DEFAULT NULL,
+  `daily_income` int(11) DEFAULT NULL,
+  `boost_reactions` varchar(1024) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `task_completed_by_me_reaction` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `release_bounty_reaction` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `updated_by` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `created` datetime NOT NULL,
+  `updated` datetime NOT NULL,
+  PRIMARY KEY (`team_id`, `channel_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
@@ -0,0 +1,12 @@
+CREATE TABLE `tips` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `channel_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `from_user_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `to_user_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `amount` int(11) NOT NULL,
+  `note` varchar(1024) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `created` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `tips_team_channel_from_created` (`team_id`, `channel_id`, `from_user_id`, `created`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
@@ -0,0 +1,16 @@
+-- Achievements are earned by users in a channel. Repeatable achievements have a row each time they're earned.
+CREATE TABLE `achievements` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `channel_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `user_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `type` varchar(64) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `message_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `created` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `achievements_team_channel_user` (`team_id`, `channel_id`, `user_id`, `type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Achievements look up a user's awarded claims in a channel.
+ALTER TABLE `bounty_claims`
+  ADD KEY `bounty_claims_team_channel_user` (`team_id`, `channel_id`, `user_id`, `status`, `updated`);
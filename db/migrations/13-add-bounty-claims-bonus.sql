@@ -0,0 +1,3 @@
+-- Fast review bonuses are minted on top of the recipient's share of the bounty.
+ALTER TABLE `bounty_claims`
+  ADD COLUMN `bonus` int(11) NOT NULL DEFAULT 0 AFTER `amount`;
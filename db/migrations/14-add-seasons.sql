@@ -0,0 +1,22 @@
+-- Earnings are tracked for the current season and archived to season_results when it ends.
+ALTER TABLE `channel_accounts`
+  ADD COLUMN `earned_this_season` int(11) NOT NULL DEFAULT 0 AFTER `spent_this_year`,
+  ADD COLUMN `spent_this_season` int(11) NOT NULL DEFAULT 0 AFTER `earned_this_season`;
+
+-- The season that was in progress at the last tickover, empty if there wasn't one.
+ALTER TABLE `bot_state`
+  ADD COLUMN `season` varchar(64) COLLATE utf8mb4_bin NOT NULL DEFAULT '';
+
+CREATE TABLE `season_results` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `team_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `channel_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `season` varchar(64) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `user_id` varchar(36) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+  `earned` int(11) NOT NULL,
+  `spent` int(11) NOT NULL,
+  `balance` int(11) NOT NULL,
+  `created` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `season_results_team_channel_season` (`team_id`, `channel_id`, `season`, `earned`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
@@ -0,0 +1,7 @@
+-- Limits on the size of bounties and how much each user can spend. A NULL value means the channel uses the config's
+-- value, zero means no limit.
+ALTER TABLE `channel_settings`
+  ADD COLUMN `min_bounty` int(11) DEFAULT NULL AFTER `release_bounty_reaction`,
+  ADD COLUMN `max_bounty` int(11) DEFAULT NULL AFTER `min_bounty`,
+  ADD COLUMN `max_boost_per_user` int(11) DEFAULT NULL AFTER `max_bounty`,
+  ADD COLUMN `daily_spend_cap` int(11) DEFAULT NULL AFTER `max_boost_per_user`;
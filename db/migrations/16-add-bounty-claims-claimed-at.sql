@@ -0,0 +1,9 @@
+-- When the user last claimed the bounty. Unlike created this is reset when a retracted claim is made again, and unlike
+-- updated it isn't changed when the claim is awarded.
+ALTER TABLE `bounty_claims`
+  ADD COLUMN `claimed_at` datetime DEFAULT NULL AFTER `bonus`;
+
+UPDATE `bounty_claims` SET `claimed_at` = `created`;
+
+ALTER TABLE `bounty_claims`
+  MODIFY COLUMN `claimed_at` datetime NOT NULL;
@@ -0,0 +1,3 @@
+-- The daily spend cap totals what a user has put towards bounties in a channel each day.
+ALTER TABLE `message_bounty_contributions`
+  ADD KEY `message_bounty_contributions_team_channel_user` (`team_id`, `channel_id`, `user_id`, `status`, `created`);
@@ -0,0 +1,10 @@
+-- Message timestamps are only unique within a channel, so bounties and claims are keyed by the workspace and channel
+-- as well as the message. The team/channel key is covered by the new primary key.
+ALTER TABLE `message_bounties`
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`team_id`, `channel_id`, `message_id`),
+  DROP KEY `message_bounties_team_channel`;
+
+ALTER TABLE `bounty_claims`
+  DROP KEY `bounty_claims_message_user`,
+  ADD UNIQUE KEY `bounty_claims_message_user` (`team_id`, `message_id`, `channel_id`, `user_id`);
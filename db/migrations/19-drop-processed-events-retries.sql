@@ -0,0 +1,3 @@
+-- Redeliveries are only acknowledged, nothing reads how many there were.
+ALTER TABLE `processed_events`
+  DROP COLUMN `retries`;
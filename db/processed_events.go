@@ -0,0 +1,91 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type ProcessedEventsRepo interface {
+	// Init will initialise our processed events repo.
+	Init() error
+
+	// MarkProcessed will record an event, returning true if it had already been recorded.
+	MarkProcessed(processedEvent *types.ProcessedEvent) (bool, error)
+
+	// Delete will remove a processed event so that it can be processed again.
+	Delete(eventId string) error
+
+	// DeleteOlderThan will remove any processed events first received before the cutoff.
+	DeleteOlderThan(cutoff time.Time) (int64, error)
+}
+
+type processedEventsRepo struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+func NewProcessedEventsRepo(
+	db *sql.DB,
+	log *logrus.Logger,
+) ProcessedEventsRepo {
+	return &processedEventsRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Init initialises the processed events repo.
+func (r *processedEventsRepo) Init() error {
+	return nil
+}
+
+// MarkProcessed will record an event, returning true if it had already been recorded.
+func (r *processedEventsRepo) MarkProcessed(processedEvent *types.ProcessedEvent) (bool, error) {
+	res, err := r.db.Exec(
+		getProcessedEventQueries()[processedEventMark],
+		processedEvent.EventId,
+		processedEvent.EventType,
+		processedEvent.TeamId,
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to mark event as processed")
+	}
+
+	// NOTE: MySQL reports 1 affected row for a new insert, 2 when the duplicate key update is applied and 0 when it
+	//       leaves the row as it was.
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check rows affected when marking event as processed")
+	}
+
+	return rowsAffected != 1, nil
+}
+
+// Delete will remove a processed event so that it can be processed again.
+func (r *processedEventsRepo) Delete(eventId string) error {
+	if _, err := r.db.Exec(
+		getProcessedEventQueries()[processedEventDelete],
+		eventId,
+	); err != nil {
+		return errors.Wrap(err, "failed to delete processed event")
+	}
+
+	return nil
+}
+
+// DeleteOlderThan will remove any processed events first received before the cutoff.
+func (r *processedEventsRepo) DeleteOlderThan(cutoff time.Time) (int64, error) {
+	res, err := r.db.Exec(
+		getProcessedEventQueries()[processedEventsDeleteOlderThan],
+		cutoff,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to delete expired processed events")
+	}
+
+	return res.RowsAffected()
+}
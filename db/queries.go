@@ -15,6 +15,8 @@ const (
 	channelAccountUpdate              = "update"
 	channelAccountSpend               = "spend"
 	channelAccountAward               = "award"
+	channelAccountRefund              = "refund"
+	channelAccountSetFrozen           = "set_frozen"
 	channelAccountActiveTodayCount    = "today_count"
 	channelAccountActiveThisWeekCount = "this_week_count"
 	channelAccountActiveThisYearCount = "this_year_count"
@@ -25,20 +27,82 @@ const (
 	channelAccountApplyIncomeAndDecay = "income_and_decay"
 	channelAccountsDistinctChannels   = "channel_accounts_distinct_channels"
 
-	messageBountiesList = "list"
-	messageBountyCreate = "create"
-	messageBountyUpdate = "update"
-	messageBountyBoost  = "boost"
+	channelAccountActiveThisSeasonCount    = "this_season_count"
+	channelAccountResetSeason              = "reset_season"
+	channelAccountSeasonBalanceReset       = "season_balance_reset"
+	channelAccountRecordSeasonBalanceReset = "record_season_balance_reset"
+
+	channelAccountsCreateWorkspaceWallets              = "create_workspace_wallets"
+	channelAccountsMergeIntoWorkspaceWallets           = "merge_into_workspace_wallets"
+	channelAccountsMoveContributionsToWorkspaceWallets = "move_contributions_to_workspace_wallets"
+	channelAccountsMoveTransactionsToWorkspaceWallets  = "move_transactions_to_workspace_wallets"
+	channelAccountsMoveTipsToWorkspaceWallets          = "move_tips_to_workspace_wallets"
+	channelAccountsMoveAchievementsToWorkspaceWallets  = "move_achievements_to_workspace_wallets"
+	channelAccountsDeleteMerged                        = "delete_merged"
+
+	messageBountiesList             = "list"
+	messageBountyCreate             = "create"
+	messageBountyUpdate             = "update"
+	messageBountyBoost              = "boost"
+	messageBountyClose              = "close"
+	messageBountyHandOver           = "hand_over"
+	messageBountiesDistinctChannels = "message_bounties_distinct_channels"
+
+	messageBountyContributionsList         = "list"
+	messageBountyContributionCreate        = "create"
+	messageBountyContributionUpdateStatus  = "update_status"
+	messageBountyContributionsContributors = "contributors"
+	messageBountyContributionsListActive   = "list_active"
+	messageBountyContributionsSumSince     = "sum_since"
+
+	installationGet    = "get"
+	installationUpsert = "upsert"
+
+	installationsCountLegacyRows = "count_legacy_rows"
+	installationsAdoptLegacyRows = "adopt_legacy_rows"
+
+	channelSettingsGet    = "get"
+	channelSettingsUpsert = "upsert"
+
+	tipCreate        = "create"
+	tipsSumSentSince = "sum_sent_since"
+
+	bountyClaimsList         = "list"
+	bountyClaimUpsert        = "upsert"
+	bountyClaimsAwardedCount = "awarded_count"
+	bountyClaimsAwardedDays  = "awarded_days"
+	bountyClaimsBonusSince   = "bonus_since"
+
+	achievementCreate  = "create"
+	achievementsCounts = "counts"
+
+	seasonResultsArchive = "archive"
+	seasonResultsList    = "list"
+	seasonResultsSeasons = "seasons"
+
+	accountTransactionsList                 = "list"
+	accountTransactionCreate                = "create"
+	accountTransactionsRecordDecay          = "record_decay"
+	accountTransactionsRecordIncome         = "record_income"
+	accountTransactionsSeedOpeningBalances  = "seed_opening_balances"
+	accountTransactionsCarryForward         = "carry_forward"
+	accountTransactionsDeleteOlderThan      = "delete_older_than"
+	accountTransactionsUnreconciledAccounts = "unreconciled_accounts"
+
+	processedEventMark             = "mark"
+	processedEventDelete           = "delete"
+	processedEventsDeleteOlderThan = "delete_older_than"
 )
 
 func getChannelAccountQueries() map[string]string {
 	return map[string]string{
 		channelAccountsDistinctChannels: `
-			SELECT DISTINCT(channel_id) FROM channel_accounts
+			SELECT DISTINCT team_id, channel_id FROM channel_accounts
 		`,
 		channelAccountsList: `
 			SELECT 
 				id,
+				team_id,
 				user_id,
 				channel_id,
 				balance,
@@ -48,14 +112,18 @@ func getChannelAccountQueries() map[string]string {
 				spent_this_week,
 				earned_this_year,
 				spent_this_year,
+				earned_this_season,
+				spent_this_season,
 				earned_all_time,
 				spent_all_time,
+				frozen,
 				created,
 				updated
 			FROM channel_accounts
 		`,
 		channelAccountCreate: `
 			INSERT INTO channel_accounts( 
+				team_id,
 				user_id,
 				channel_id,
 				balance,
@@ -65,6 +133,8 @@ func getChannelAccountQueries() map[string]string {
 				spent_this_week,
 				earned_this_year,
 				spent_this_year,
+				earned_this_season,
+				spent_this_season,
 				earned_all_time,
 				spent_all_time,
 				created,
@@ -73,6 +143,9 @@ func getChannelAccountQueries() map[string]string {
 				?,
 				?,
 				?,
+				?,
+				0,
+				0,
 				0,
 				0,
 				0,
@@ -94,10 +167,11 @@ func getChannelAccountQueries() map[string]string {
 				spent_this_week = ?,
 				earned_this_year = ?,
 				spent_this_year = ?,
+				earned_this_season = ?,
+				spent_this_season = ?,
 				earned_all_time = ?,
 				spent_all_time = ?,
 				updated = CURRENT_TIMESTAMP
-			) 
 			WHERE id = ?
 		`,
 		channelAccountSpend: `
@@ -106,9 +180,11 @@ func getChannelAccountQueries() map[string]string {
 				spent_today = spent_today + ?,
 				spent_this_week = spent_this_week + ?,
 				spent_this_year = spent_this_year + ?,
+				spent_this_season = spent_this_season + ?,
 				spent_all_time = spent_all_time + ?,
 				updated = CURRENT_TIMESTAMP
 			WHERE id = ?
+				AND balance >= ?
 		`,
 		channelAccountAward: `
 			UPDATE channel_accounts
@@ -116,33 +192,69 @@ func getChannelAccountQueries() map[string]string {
 				earned_today = earned_today + ?,
 				earned_this_week = earned_this_week + ?,
 				earned_this_year = earned_this_year + ?,
+				earned_this_season = earned_this_season + ?,
 				earned_all_time = earned_all_time + ?,
 				updated = CURRENT_TIMESTAMP
 			WHERE id = ?
 		`,
+		channelAccountRefund: `
+			UPDATE channel_accounts
+			SET balance = balance + ?,
+				spent_today = GREATEST(spent_today - ?, 0),
+				spent_this_week = GREATEST(spent_this_week - ?, 0),
+				spent_this_year = GREATEST(spent_this_year - ?, 0),
+				spent_this_season = GREATEST(spent_this_season - ?, 0),
+				spent_all_time = GREATEST(spent_all_time - ?, 0),
+				updated = CURRENT_TIMESTAMP
+			WHERE id = ?
+		`,
+		channelAccountSetFrozen: `
+			UPDATE channel_accounts
+			SET frozen = ?,
+				updated = CURRENT_TIMESTAMP
+			WHERE team_id = ?
+				AND user_id = ?
+				AND (channel_id = ? OR ? = '')
+		`,
 		channelAccountActiveTodayCount: `
 			SELECT COUNT(1)
 			FROM channel_accounts
 			WHERE (spent_today > 0 || earned_today > 0)
+				AND team_id = ?
 				AND channel_id = ?
+				AND frozen = 0
 			`,
 		channelAccountActiveThisWeekCount: `
 			SELECT COUNT(1)
 			FROM channel_accounts
 			WHERE (spent_this_week > 0 || earned_this_week > 0)
+				AND team_id = ?
 				AND channel_id = ?
+				AND frozen = 0
 		`,
 		channelAccountActiveThisYearCount: `
 			SELECT COUNT(1)
 			FROM channel_accounts
 			WHERE (spent_this_year > 0 || earned_this_year > 0)
+				AND team_id = ?
+				AND channel_id = ?
+				AND frozen = 0
+		`,
+		channelAccountActiveThisSeasonCount: `
+			SELECT COUNT(1)
+			FROM channel_accounts
+			WHERE (spent_this_season > 0 || earned_this_season > 0)
+				AND team_id = ?
 				AND channel_id = ?
+				AND frozen = 0
 		`,
 		channelAccountActiveAllTimeCount: `
 			SELECT COUNT(1)
 			FROM channel_accounts
 			WHERE (spent_all_time > 0 || earned_all_time > 0)
-			       AND channel_id = ?
+				AND team_id = ?
+				AND channel_id = ?
+				AND frozen = 0
 		`,
 		channelAccountResetDaily: `
 			UPDATE channel_accounts
@@ -159,10 +271,157 @@ func getChannelAccountQueries() map[string]string {
 			SET spent_this_year = 0,
 				earned_this_year = 0
 		`,
-		channelAccountApplyIncomeAndDecay: `
+		channelAccountResetSeason: `
+			UPDATE channel_accounts
+			SET spent_this_season = 0,
+				earned_this_season = 0
+		`,
+		// The ledger is given the amount removed before the balances are reset.
+		channelAccountRecordSeasonBalanceReset: `
+			INSERT INTO account_transactions(
+				team_id,
+				channel_account_id,
+				type,
+				amount,
+				reason,
+				message_id,
+				created
+			)
+			SELECT
+				team_id,
+				id,
+				?,
+				-FLOOR(balance * ? / 100),
+				?,
+				'',
+				CURRENT_TIMESTAMP
+			FROM channel_accounts
+			WHERE FLOOR(balance * ? / 100) > 0
+		`,
+		channelAccountSeasonBalanceReset: `
 			UPDATE channel_accounts
-			SET balance = (balance - ? + ?)
-			WHERE (balance - ? + ?) > 0
+			SET balance = balance - FLOOR(balance * ? / 100),
+				updated = CURRENT_TIMESTAMP
+		`,
+		// The balance expressions are provided by incomeAndDecayExpressions, they only contain integers.
+		channelAccountApplyIncomeAndDecay: `
+			UPDATE channel_accounts ca
+			LEFT JOIN channel_settings cs ON cs.team_id = ca.team_id AND cs.channel_id = ca.channel_id
+			SET ca.balance = %[2]s
+			WHERE ca.frozen = 0
+		`,
+		// Wallets start empty and are only frozen if every one of the user's channel accounts is.
+		channelAccountsCreateWorkspaceWallets: `
+			INSERT INTO channel_accounts(
+				team_id,
+				user_id,
+				channel_id,
+				balance,
+				earned_today,
+				spent_today,
+				earned_this_week,
+				spent_this_week,
+				earned_this_year,
+				spent_this_year,
+				earned_this_season,
+				spent_this_season,
+				earned_all_time,
+				spent_all_time,
+				frozen,
+				created,
+				updated
+			)
+			SELECT
+				ca.team_id,
+				ca.user_id,
+				?,
+				0,
+				0,
+				0,
+				0,
+				0,
+				0,
+				0,
+				0,
+				0,
+				0,
+				0,
+				MIN(ca.frozen),
+				MIN(ca.created),
+				CURRENT_TIMESTAMP
+			FROM channel_accounts ca
+			WHERE ca.channel_id <> ?
+				AND NOT EXISTS (
+					SELECT 1
+					FROM channel_accounts w
+					WHERE w.team_id = ca.team_id
+						AND w.user_id = ca.user_id
+						AND w.channel_id = ?
+				)
+			GROUP BY ca.team_id, ca.user_id
+		`,
+		channelAccountsMergeIntoWorkspaceWallets: `
+			UPDATE channel_accounts w
+			INNER JOIN (
+				SELECT
+					team_id,
+					user_id,
+					SUM(balance) AS balance,
+					SUM(earned_today) AS earned_today,
+					SUM(spent_today) AS spent_today,
+					SUM(earned_this_week) AS earned_this_week,
+					SUM(spent_this_week) AS spent_this_week,
+					SUM(earned_this_year) AS earned_this_year,
+					SUM(spent_this_year) AS spent_this_year,
+					SUM(earned_this_season) AS earned_this_season,
+					SUM(spent_this_season) AS spent_this_season,
+					SUM(earned_all_time) AS earned_all_time,
+					SUM(spent_all_time) AS spent_all_time
+				FROM channel_accounts
+				WHERE channel_id <> ?
+				GROUP BY team_id, user_id
+			) ca ON ca.team_id = w.team_id AND ca.user_id = w.user_id
+			SET w.balance = w.balance + ca.balance,
+				w.earned_today = w.earned_today + ca.earned_today,
+				w.spent_today = w.spent_today + ca.spent_today,
+				w.earned_this_week = w.earned_this_week + ca.earned_this_week,
+				w.spent_this_week = w.spent_this_week + ca.spent_this_week,
+				w.earned_this_year = w.earned_this_year + ca.earned_this_year,
+				w.spent_this_year = w.spent_this_year + ca.spent_this_year,
+				w.earned_this_season = w.earned_this_season + ca.earned_this_season,
+				w.spent_this_season = w.spent_this_season + ca.spent_this_season,
+				w.earned_all_time = w.earned_all_time + ca.earned_all_time,
+				w.spent_all_time = w.spent_all_time + ca.spent_all_time,
+				w.updated = CURRENT_TIMESTAMP
+			WHERE w.channel_id = ?
+		`,
+		channelAccountsMoveContributionsToWorkspaceWallets: `
+			UPDATE message_bounty_contributions mbc
+			INNER JOIN channel_accounts ca ON ca.id = mbc.channel_account_id
+			INNER JOIN channel_accounts w ON w.team_id = ca.team_id AND w.user_id = ca.user_id AND w.channel_id = ?
+			SET mbc.channel_account_id = w.id
+			WHERE ca.channel_id <> ?
+		`,
+		channelAccountsMoveTransactionsToWorkspaceWallets: `
+			UPDATE account_transactions t
+			INNER JOIN channel_accounts ca ON ca.id = t.channel_account_id
+			INNER JOIN channel_accounts w ON w.team_id = ca.team_id AND w.user_id = ca.user_id AND w.channel_id = ?
+			SET t.channel_account_id = w.id
+			WHERE ca.channel_id <> ?
+		`,
+		channelAccountsMoveTipsToWorkspaceWallets: `
+			UPDATE tips
+			SET channel_id = ?
+			WHERE channel_id <> ?
+		`,
+		channelAccountsMoveAchievementsToWorkspaceWallets: `
+			UPDATE achievements
+			SET channel_id = ?
+			WHERE channel_id <> ?
+		`,
+		channelAccountsDeleteMerged: `
+			DELETE FROM channel_accounts
+			WHERE channel_id <> ?
 		`,
 	}
 }
@@ -170,12 +429,12 @@ func getChannelAccountQueries() map[string]string {
 func getBotMessageQueries() map[string]string {
 	return map[string]string{
 		botMessagesList: `
-			SELECT id, message_id, sent_message_id, channel_id, reaction, status, target_user_id, created, updated
+			SELECT id, team_id, message_id, sent_message_id, channel_id, reaction, status, target_user_id, created, updated
 			FROM bot_messages
 		`,
 		botMessageCreate: `
-			INSERT INTO bot_messages(message_id, sent_message_id, channel_id, reaction, status, target_user_id, created, updated)
-			VALUES(?, ?, ?, ?, 1, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+			INSERT INTO bot_messages(team_id, message_id, sent_message_id, channel_id, reaction, status, target_user_id, created, updated)
+			VALUES(?, ?, ?, ?, ?, 1, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		`,
 		botMessageUpdate: `
 			UPDATE bot_messages
@@ -188,14 +447,19 @@ func getBotMessageQueries() map[string]string {
 
 func getMessageBountyQueries() map[string]string {
 	return map[string]string{
+		messageBountiesDistinctChannels: `
+			SELECT DISTINCT team_id, channel_id FROM message_bounties
+		`,
 		messageBountiesList: `
 			SELECT 
 				message_id,
+				team_id,
 				user_id,
 				channel_id,
 				current_bounty,
 				status,
 				awarded_to,
+				expiry_warned,
 				created,
 				updated
 			FROM message_bounties
@@ -203,6 +467,7 @@ func getMessageBountyQueries() map[string]string {
 		messageBountyCreate: `
 			INSERT INTO message_bounties( 
 				message_id,
+				team_id,
 				user_id,
 				channel_id,
 				current_bounty,
@@ -217,23 +482,146 @@ func getMessageBountyQueries() map[string]string {
 				?,
 				?,
 				?,
+				?,
 				CURRENT_TIMESTAMP,
 				CURRENT_TIMESTAMP
 			)
 		`,
 		messageBountyUpdate: `
 			UPDATE message_bounties
-			SET current_bounty = ?,
-				status = ?,
+			SET user_id = ?,
 				awarded_to = ?,
+				expiry_warned = ?,
 				updated = CURRENT_TIMESTAMP
-			WHERE message_id = ?
+			WHERE team_id = ?
+				AND channel_id = ?
+				AND message_id = ?
 		`,
 		messageBountyBoost: `
 			UPDATE message_bounties
 			SET current_bounty = current_bounty + ?,
 				updated = CURRENT_TIMESTAMP
-			WHERE message_id = ?
+			WHERE team_id = ?
+				AND channel_id = ?
+				AND message_id = ?
+				AND status = ?
+		`,
+		messageBountyClose: `
+			UPDATE message_bounties
+			SET status = ?,
+				awarded_to = ?,
+				updated = CURRENT_TIMESTAMP
+			WHERE team_id = ?
+				AND channel_id = ?
+				AND message_id = ?
+				AND status = ?
+				AND current_bounty = ?
+		`,
+		messageBountyHandOver: `
+			UPDATE message_bounties
+			SET user_id = ?,
+				updated = CURRENT_TIMESTAMP
+			WHERE team_id = ?
+				AND channel_id = ?
+				AND message_id = ?
+				AND user_id = ?
+				AND status = ?
+		`,
+	}
+}
+
+func getMessageBountyContributionQueries() map[string]string {
+	return map[string]string{
+		messageBountyContributionsList: `
+			SELECT
+				id,
+				team_id,
+				message_id,
+				channel_id,
+				user_id,
+				channel_account_id,
+				reaction,
+				amount,
+				status,
+				created,
+				updated
+			FROM message_bounty_contributions
+		`,
+		// Unlike list this isn't paged, a bounty has to be refunded or handed over in full.
+		messageBountyContributionsListActive: `
+			SELECT
+				id,
+				team_id,
+				message_id,
+				channel_id,
+				user_id,
+				channel_account_id,
+				reaction,
+				amount,
+				status,
+				created,
+				updated
+			FROM message_bounty_contributions
+			WHERE team_id = ?
+				AND message_id = ?
+				AND channel_id = ?
+				AND status = ?
+			ORDER BY id DESC
+		`,
+		messageBountyContributionCreate: `
+			INSERT INTO message_bounty_contributions(
+				team_id,
+				message_id,
+				channel_id,
+				user_id,
+				channel_account_id,
+				reaction,
+				amount,
+				status,
+				created,
+				updated
+			) VALUES (
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				CURRENT_TIMESTAMP,
+				CURRENT_TIMESTAMP
+			)
+		`,
+		messageBountyContributionsContributors: `
+			SELECT
+				user_id,
+				SUM(amount) AS total
+			FROM message_bounty_contributions
+			WHERE team_id = ?
+				AND message_id = ?
+				AND channel_id = ?
+				AND status = ?
+			GROUP BY user_id
+			ORDER BY total DESC, MIN(id) ASC
+		`,
+		// A locking read so that contributions committed by a concurrent boost are included.
+		messageBountyContributionsSumSince: `
+			SELECT COALESCE(SUM(amount), 0)
+			FROM message_bounty_contributions
+			WHERE team_id = ?
+				AND channel_id = ?
+				AND user_id = ?
+				AND status = ?
+				AND created >= ?
+			LOCK IN SHARE MODE
+		`,
+		messageBountyContributionUpdateStatus: `
+			UPDATE message_bounty_contributions
+			SET status = ?,
+				updated = CURRENT_TIMESTAMP
+			WHERE id = ?
+				AND status = ?
 		`,
 	}
 }
@@ -247,7 +635,8 @@ func getBotStateQueries() map[string]string {
 				day_tickover,
 				week_tickover,
 				month_tickover,
-				year_tickover
+				year_tickover,
+				season
 			FROM bot_state
 			WHERE id = (
 				SELECT MAX(id)
@@ -262,8 +651,524 @@ func getBotStateQueries() map[string]string {
 			SET day_tickover = ?,
 				week_tickover = ?,
 				month_tickover = ?,
-				year_tickover = ?
+				year_tickover = ?,
+				season = ?
+			WHERE id = ?
+		`,
+	}
+}
+
+func getChannelSettingsQueries() map[string]string {
+	return map[string]string{
+		channelSettingsGet: `
+			SELECT
+				team_id,
+				channel_id,
+				daily_decay,
+				daily_income,
+				boost_reactions,
+				task_completed_by_me_reaction,
+				release_bounty_reaction,
+				min_bounty,
+				max_bounty,
+				max_boost_per_user,
+				daily_spend_cap,
+				updated_by,
+				created,
+				updated
+			FROM channel_settings
+			WHERE team_id = ?
+				AND channel_id = ?
+		`,
+		channelSettingsUpsert: `
+			INSERT INTO channel_settings(
+				team_id,
+				channel_id,
+				daily_decay,
+				daily_income,
+				boost_reactions,
+				task_completed_by_me_reaction,
+				release_bounty_reaction,
+				min_bounty,
+				max_bounty,
+				max_boost_per_user,
+				daily_spend_cap,
+				updated_by,
+				created,
+				updated
+			) VALUES (
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				CURRENT_TIMESTAMP,
+				CURRENT_TIMESTAMP
+			)
+			ON DUPLICATE KEY UPDATE
+				daily_decay = VALUES(daily_decay),
+				daily_income = VALUES(daily_income),
+				boost_reactions = VALUES(boost_reactions),
+				task_completed_by_me_reaction = VALUES(task_completed_by_me_reaction),
+				release_bounty_reaction = VALUES(release_bounty_reaction),
+				min_bounty = VALUES(min_bounty),
+				max_bounty = VALUES(max_bounty),
+				max_boost_per_user = VALUES(max_boost_per_user),
+				daily_spend_cap = VALUES(daily_spend_cap),
+				updated_by = VALUES(updated_by),
+				updated = CURRENT_TIMESTAMP
+		`,
+	}
+}
+
+func getTipQueries() map[string]string {
+	return map[string]string{
+		tipCreate: `
+			INSERT INTO tips(
+				team_id,
+				channel_id,
+				from_user_id,
+				to_user_id,
+				amount,
+				note,
+				created
+			) VALUES (
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				CURRENT_TIMESTAMP
+			)
+		`,
+		tipsSumSentSince: `
+			SELECT COALESCE(SUM(amount), 0)
+			FROM tips
+			WHERE team_id = ?
+				AND channel_id = ?
+				AND from_user_id = ?
+				AND created >= ?
+		`,
+	}
+}
+
+func getSeasonResultQueries() map[string]string {
+	return map[string]string{
+		// Frozen accounts and those that didn't take part in the season are left out.
+		seasonResultsArchive: `
+			INSERT INTO season_results(
+				team_id,
+				channel_id,
+				season,
+				user_id,
+				earned,
+				spent,
+				balance,
+				created
+			)
+			SELECT
+				team_id,
+				channel_id,
+				?,
+				user_id,
+				earned_this_season,
+				spent_this_season,
+				balance,
+				CURRENT_TIMESTAMP
+			FROM channel_accounts
+			WHERE frozen = 0
+				AND (earned_this_season > 0 OR spent_this_season > 0)
+		`,
+		seasonResultsList: `
+			SELECT
+				id,
+				team_id,
+				channel_id,
+				season,
+				user_id,
+				earned,
+				spent,
+				balance,
+				created
+			FROM season_results
+			WHERE team_id = ?
+				AND channel_id = ?
+				AND season = ?
+			ORDER BY earned DESC, id ASC
+			LIMIT ?
+		`,
+		seasonResultsSeasons: `
+			SELECT season
+			FROM season_results
+			WHERE team_id = ?
+				AND channel_id = ?
+			GROUP BY season
+			ORDER BY MAX(created) DESC
+		`,
+	}
+}
+
+func getAchievementQueries() map[string]string {
+	return map[string]string{
+		achievementCreate: `
+			INSERT INTO achievements(
+				team_id,
+				channel_id,
+				user_id,
+				type,
+				message_id,
+				created
+			) VALUES (
+				?,
+				?,
+				?,
+				?,
+				?,
+				CURRENT_TIMESTAMP
+			)
+		`,
+		achievementsCounts: `
+			SELECT
+				type,
+				COUNT(1),
+				MAX(created)
+			FROM achievements
+			WHERE team_id = ?
+				AND channel_id = ?
+				AND user_id = ?
+			GROUP BY type
+		`,
+	}
+}
+
+func getBountyClaimQueries() map[string]string {
+	return map[string]string{
+		// An empty user id counts the bounties awarded to anyone in the channel, an empty channel id counts every channel.
+		bountyClaimsAwardedCount: `
+			SELECT COUNT(DISTINCT channel_id, message_id)
+			FROM bounty_claims
+			WHERE team_id = ?
+				AND (channel_id = ? OR ? = '')
+				AND (user_id = ? OR ? = '')
+				AND status = ?
+				AND updated >= ?
+		`,
+		// A locking read so that bonuses committed by a concurrent award are included.
+		bountyClaimsBonusSince: `
+			SELECT COALESCE(SUM(bonus), 0)
+			FROM bounty_claims
+			WHERE team_id = ?
+				AND channel_id = ?
+				AND user_id = ?
+				AND status = ?
+				AND updated >= ?
+			LOCK IN SHARE MODE
+		`,
+		// An empty channel id includes every channel.
+		bountyClaimsAwardedDays: `
+			SELECT DISTINCT DATE(updated) AS awarded_day
+			FROM bounty_claims
+			WHERE team_id = ?
+				AND (channel_id = ? OR ? = '')
+				AND user_id = ?
+				AND status = ?
+				AND updated >= ?
+			ORDER BY awarded_day DESC
+		`,
+		bountyClaimsList: `
+			SELECT
+				id,
+				team_id,
+				message_id,
+				channel_id,
+				user_id,
+				status,
+				amount,
+				bonus,
+				claimed_at,
+				created,
+				updated
+			FROM bounty_claims
+		`,
+		// A claim that's made again after being retracted or awarded is moved to the back of the queue.
+		bountyClaimUpsert: `
+			INSERT INTO bounty_claims(
+				team_id,
+				message_id,
+				channel_id,
+				user_id,
+				status,
+				amount,
+				bonus,
+				claimed_at,
+				created,
+				updated
+			) VALUES (
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				CURRENT_TIMESTAMP,
+				CURRENT_TIMESTAMP,
+				CURRENT_TIMESTAMP
+			)
+			ON DUPLICATE KEY UPDATE
+				claimed_at = IF(VALUES(status) = ? AND status != ?, CURRENT_TIMESTAMP, claimed_at),
+				status = VALUES(status),
+				amount = VALUES(amount),
+				bonus = VALUES(bonus),
+				updated = CURRENT_TIMESTAMP
+		`,
+	}
+}
+
+func getAccountTransactionQueries() map[string]string {
+	return map[string]string{
+		accountTransactionsList: `
+			SELECT
+				id,
+				team_id,
+				channel_account_id,
+				type,
+				amount,
+				reason,
+				message_id,
+				created
+			FROM account_transactions
+		`,
+		accountTransactionCreate: `
+			INSERT INTO account_transactions(
+				team_id,
+				channel_account_id,
+				type,
+				amount,
+				reason,
+				message_id,
+				created
+			)
+			SELECT
+				team_id,
+				id,
+				?,
+				?,
+				?,
+				?,
+				CURRENT_TIMESTAMP
+			FROM channel_accounts
 			WHERE id = ?
 		`,
+		// The balance expressions are provided by incomeAndDecayExpressions, they only contain integers. Anything the
+		// cap removes is recorded as decay so that the two transactions add up to the change in balance.
+		accountTransactionsRecordDecay: `
+			INSERT INTO account_transactions(
+				team_id,
+				channel_account_id,
+				type,
+				amount,
+				reason,
+				message_id,
+				created
+			)
+			SELECT
+				ca.team_id,
+				ca.id,
+				?,
+				LEAST(%[1]s, %[2]s) - ca.balance,
+				?,
+				'',
+				CURRENT_TIMESTAMP
+			FROM channel_accounts ca
+			LEFT JOIN channel_settings cs ON cs.team_id = ca.team_id AND cs.channel_id = ca.channel_id
+			WHERE ca.frozen = 0
+				AND LEAST(%[1]s, %[2]s) - ca.balance != 0
+		`,
+		accountTransactionsRecordIncome: `
+			INSERT INTO account_transactions(
+				team_id,
+				channel_account_id,
+				type,
+				amount,
+				reason,
+				message_id,
+				created
+			)
+			SELECT
+				ca.team_id,
+				ca.id,
+				?,
+				%[2]s - LEAST(%[1]s, %[2]s),
+				?,
+				'',
+				CURRENT_TIMESTAMP
+			FROM channel_accounts ca
+			LEFT JOIN channel_settings cs ON cs.team_id = ca.team_id AND cs.channel_id = ca.channel_id
+			WHERE ca.frozen = 0
+				AND %[2]s - LEAST(%[1]s, %[2]s) != 0
+		`,
+		accountTransactionsSeedOpeningBalances: `
+			INSERT INTO account_transactions(
+				team_id,
+				channel_account_id,
+				type,
+				amount,
+				reason,
+				message_id,
+				created
+			)
+			SELECT
+				ca.team_id,
+				ca.id,
+				?,
+				ca.balance,
+				?,
+				'',
+				CURRENT_TIMESTAMP
+			FROM channel_accounts ca
+			WHERE NOT EXISTS (
+				SELECT 1
+				FROM account_transactions t
+				WHERE t.channel_account_id = ca.id
+			)
+		`,
+		accountTransactionsCarryForward: `
+			INSERT INTO account_transactions(
+				team_id,
+				channel_account_id,
+				type,
+				amount,
+				reason,
+				message_id,
+				created
+			)
+			SELECT
+				team_id,
+				channel_account_id,
+				?,
+				SUM(amount),
+				?,
+				'',
+				?
+			FROM account_transactions
+			WHERE created < ?
+			GROUP BY team_id, channel_account_id
+		`,
+		accountTransactionsDeleteOlderThan: `
+			DELETE FROM account_transactions
+			WHERE created < ?
+		`,
+		accountTransactionsUnreconciledAccounts: `
+			SELECT
+				ca.id,
+				ca.balance,
+				COALESCE(SUM(t.amount), 0)
+			FROM channel_accounts ca
+			LEFT JOIN account_transactions t ON t.channel_account_id = ca.id
+			GROUP BY ca.id, ca.balance
+			HAVING ca.balance <> COALESCE(SUM(t.amount), 0)
+			LIMIT ?
+		`,
+	}
+}
+
+func getProcessedEventQueries() map[string]string {
+	return map[string]string{
+		processedEventMark: `
+			INSERT INTO processed_events(
+				event_id,
+				event_type,
+				team_id,
+				created,
+				updated
+			) VALUES (
+				?,
+				?,
+				?,
+				CURRENT_TIMESTAMP,
+				CURRENT_TIMESTAMP
+			)
+			ON DUPLICATE KEY UPDATE
+				updated = CURRENT_TIMESTAMP
+		`,
+		processedEventDelete: `
+			DELETE FROM processed_events
+			WHERE event_id = ?
+		`,
+		processedEventsDeleteOlderThan: `
+			DELETE FROM processed_events
+			WHERE created < ?
+		`,
+	}
+}
+
+func getInstallationQueries() map[string]string {
+	return map[string]string{
+		installationGet: `
+			SELECT
+				team_id,
+				team_name,
+				app_id,
+				bot_user_id,
+				bot_token,
+				scope,
+				installed_by,
+				created,
+				updated
+			FROM installations
+			WHERE team_id = ?
+		`,
+		installationUpsert: `
+			INSERT INTO installations(
+				team_id,
+				team_name,
+				app_id,
+				bot_user_id,
+				bot_token,
+				scope,
+				installed_by,
+				created,
+				updated
+			) VALUES (
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				CURRENT_TIMESTAMP,
+				CURRENT_TIMESTAMP
+			)
+			ON DUPLICATE KEY UPDATE
+				team_name = VALUES(team_name),
+				app_id = VALUES(app_id),
+				bot_user_id = VALUES(bot_user_id),
+				bot_token = VALUES(bot_token),
+				scope = VALUES(scope),
+				installed_by = VALUES(installed_by),
+				updated = CURRENT_TIMESTAMP
+		`,
+		// Only accounts and bounties are counted, the other tables follow them.
+		installationsCountLegacyRows: `
+			SELECT
+				(SELECT COUNT(1) FROM channel_accounts WHERE team_id = '') +
+				(SELECT COUNT(1) FROM message_bounties WHERE team_id = '')
+		`,
+		// The table is one of legacyTeamTables.
+		installationsAdoptLegacyRows: `
+			UPDATE %v
+			SET team_id = ?
+			WHERE team_id = ''
+		`,
 	}
 }
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type SeasonResultsRepo interface {
+	// Init will initialise our season results repo.
+	Init() error
+
+	// WithTx will return a copy of the repo that runs its queries in the transaction.
+	WithTx(tx *sql.Tx) SeasonResultsRepo
+
+	// Archive will record the final standings of the season for every channel.
+	Archive(season string) (int64, error)
+
+	// List will return the final standings of the season in the channel, highest earner first.
+	List(teamId string, channelId string, season string, limit int) ([]*types.SeasonResult, error)
+
+	// Seasons will return the names of the seasons archived for the channel, most recent first.
+	Seasons(teamId string, channelId string) ([]string, error)
+}
+
+type seasonResultsRepo struct {
+	db  querier
+	log *logrus.Logger
+}
+
+func NewSeasonResultsRepo(
+	db *sql.DB,
+	log *logrus.Logger,
+) SeasonResultsRepo {
+	return &seasonResultsRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Init initialises the season results repo.
+func (r *seasonResultsRepo) Init() error {
+	return nil
+}
+
+// WithTx returns a copy of the repo that runs its queries in the transaction.
+func (r *seasonResultsRepo) WithTx(tx *sql.Tx) SeasonResultsRepo {
+	return &seasonResultsRepo{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+// Archive copies the season's earnings of every active account into season_results. It should be run before the
+// season tracking is reset. Returns the number of results recorded.
+func (r *seasonResultsRepo) Archive(season string) (int64, error) {
+	res, err := r.db.Exec(getSeasonResultQueries()[seasonResultsArchive], season)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to archive season: %v", season)
+	}
+
+	return res.RowsAffected()
+}
+
+// List will return the final standings of the season in the channel, highest earner first.
+func (r *seasonResultsRepo) List(teamId string, channelId string, season string, limit int) ([]*types.SeasonResult, error) {
+	rows, err := r.db.Query(
+		getSeasonResultQueries()[seasonResultsList],
+		teamId,
+		channelId,
+		season,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list results for season: %v", season)
+	}
+
+	defer rows.Close()
+
+	var results []*types.SeasonResult
+	for rows.Next() {
+		var (
+			result  types.SeasonResult
+			created time.Time
+		)
+
+		if err = rows.Scan(
+			&result.Id,
+			&result.TeamId,
+			&result.ChannelId,
+			&result.Season,
+			&result.UserId,
+			&result.Earned,
+			&result.Spent,
+			&result.Balance,
+			&created,
+		); err != nil {
+			return nil, err
+		}
+
+		result.Created = *timestamppb.New(created)
+		results = append(results, &result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to list results for season: %v", season)
+	}
+
+	return results, nil
+}
+
+// Seasons will return the names of the seasons archived for the channel, most recent first.
+func (r *seasonResultsRepo) Seasons(teamId string, channelId string) ([]string, error) {
+	rows, err := r.db.Query(getSeasonResultQueries()[seasonResultsSeasons], teamId, channelId)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list archived seasons")
+	}
+
+	defer rows.Close()
+
+	var seasons []string
+	for rows.Next() {
+		var season string
+		if err = rows.Scan(&season); err != nil {
+			return nil, err
+		}
+
+		seasons = append(seasons, season)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to list archived seasons")
+	}
+
+	return seasons, nil
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type TipsRepo interface {
+	// Init will initialise our tips repo.
+	Init() error
+
+	// WithTx will return a copy of the repo that runs its queries in the transaction.
+	WithTx(tx *sql.Tx) TipsRepo
+
+	// Create will record a tip.
+	Create(tip *types.Tip) error
+
+	// SumSentSince will return the total the user has tipped in the channel since the provided time.
+	SumSentSince(teamId string, channelId string, userId string, since time.Time) (int, error)
+}
+
+type tipsRepo struct {
+	db  querier
+	log *logrus.Logger
+}
+
+func NewTipsRepo(
+	db *sql.DB,
+	log *logrus.Logger,
+) TipsRepo {
+	return &tipsRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Init initialises the tips repo.
+func (r *tipsRepo) Init() error {
+	return nil
+}
+
+// WithTx returns a copy of the repo that runs its queries in the transaction.
+func (r *tipsRepo) WithTx(tx *sql.Tx) TipsRepo {
+	return &tipsRepo{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+// Create will record a tip.
+func (r *tipsRepo) Create(tip *types.Tip) error {
+	if _, err := r.db.Exec(
+		getTipQueries()[tipCreate],
+		tip.TeamId,
+		tip.ChannelId,
+		tip.FromUserId,
+		tip.ToUserId,
+		tip.Amount,
+		tip.Note,
+	); err != nil {
+		return errors.Wrap(err, "failed to create tip")
+	}
+
+	return nil
+}
+
+// SumSentSince will return the total the user has tipped in the channel since the provided time.
+func (r *tipsRepo) SumSentSince(teamId string, channelId string, userId string, since time.Time) (int, error) {
+	var total int
+	if err := r.db.QueryRow(
+		getTipQueries()[tipsSumSentSince],
+		teamId,
+		channelId,
+		userId,
+		since,
+	).Scan(&total); err != nil {
+		return 0, errors.Wrap(err, "failed to total tips sent")
+	}
+
+	return total, nil
+}
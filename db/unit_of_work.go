@@ -0,0 +1,61 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx so that a repo can be bound to a transaction with WithTx.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// UnitOfWork runs a group of repo calls in a single transaction so that they either all succeed or none of them do.
+type UnitOfWork struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+func NewUnitOfWork(
+	db *sql.DB,
+	log *logrus.Logger,
+) *UnitOfWork {
+	return &UnitOfWork{
+		db:  db,
+		log: log,
+	}
+}
+
+// Run begins a transaction and passes it to fn, repos should be bound to it with WithTx. The transaction is committed
+// if fn succeeds and rolled back if it returns an error or panics.
+func (u *UnitOfWork) Run(fn func(tx *sql.Tx) error) error {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			u.log.WithError(rollbackErr).Error("Failed to roll back transaction.")
+		}
+
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
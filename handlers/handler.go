@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+
 	"github.com/buzzology/slack_bot/db"
 	"github.com/buzzology/slack_bot/service"
 	"github.com/buzzology/slack_bot/service/api"
@@ -13,9 +15,21 @@ type SlackBotHandler struct {
 	log                    logrus.FieldLogger
 	channelAccountsRepo    db.ChannelAccountsRepo
 	messageBountiesRepo    db.MessageBountiesRepo
+	contributionsRepo      db.MessageBountyContributionsRepo
+	bountyClaimsRepo       db.BountyClaimsRepo
 	channelAccountsService *service.ChannelAccountsService
+	messageBountiesService *service.MessageBountiesService
+	channelSettingsService *service.ChannelSettingsService
+	tipsService            *service.TipsService
+	achievementsService    *service.AchievementsService
+	seasonsService         *service.SeasonsService
 	botMessagesService     *service.BotMessagesService
 	botMessagesRepo        db.BotMessagesRepo
+	processedEventsRepo    db.ProcessedEventsRepo
+	eventDispatcher        service.IEventDispatcher
+	installationsService   *service.InstallationsService
+	eventRegistry          *api.SlackEventRegistry
+	unitOfWork             *db.UnitOfWork
 }
 
 func NewSlackBotHandler(
@@ -24,18 +38,57 @@ func NewSlackBotHandler(
 	apiClient *api.SlackApiClient,
 	channelAccountsRepo db.ChannelAccountsRepo,
 	messageBountiesRepo db.MessageBountiesRepo,
+	contributionsRepo db.MessageBountyContributionsRepo,
+	bountyClaimsRepo db.BountyClaimsRepo,
 	channelAccountsService *service.ChannelAccountsService,
+	messageBountiesService *service.MessageBountiesService,
+	channelSettingsService *service.ChannelSettingsService,
+	tipsService *service.TipsService,
+	achievementsService *service.AchievementsService,
+	seasonsService *service.SeasonsService,
 	botMessagesService *service.BotMessagesService,
 	botMessagesRepo db.BotMessagesRepo,
+	processedEventsRepo db.ProcessedEventsRepo,
+	eventDispatcher service.IEventDispatcher,
+	installationsService *service.InstallationsService,
+	unitOfWork *db.UnitOfWork,
 ) *SlackBotHandler {
-	return &SlackBotHandler{
+	h := &SlackBotHandler{
 		config:                 config,
 		apiClient:              apiClient,
 		log:                    log,
 		channelAccountsRepo:    channelAccountsRepo,
 		messageBountiesRepo:    messageBountiesRepo,
+		contributionsRepo:      contributionsRepo,
+		bountyClaimsRepo:       bountyClaimsRepo,
 		channelAccountsService: channelAccountsService,
+		messageBountiesService: messageBountiesService,
+		channelSettingsService: channelSettingsService,
+		tipsService:            tipsService,
+		achievementsService:    achievementsService,
+		seasonsService:         seasonsService,
 		botMessagesService:     botMessagesService,
 		botMessagesRepo:        botMessagesRepo,
+		processedEventsRepo:    processedEventsRepo,
+		eventDispatcher:        eventDispatcher,
+		installationsService:   installationsService,
+		eventRegistry:          api.NewSlackEventRegistry(),
+		unitOfWork:             unitOfWork,
 	}
+
+	h.registerEventHandlers()
+
+	return h
+}
+
+// getChannelConfig returns the settings that apply to the channel. The config's settings are used if the channel's
+// can't be retrieved.
+func (h *SlackBotHandler) getChannelConfig(ctx context.Context, channelId string) *service.ChannelConfig {
+	channelConfig, err := h.channelSettingsService.Get(ctx, channelId)
+	if err != nil {
+		h.log.WithError(err).WithField("channel_id", channelId).Warn("Failed to retrieve channel settings, using the defaults.")
+		return h.channelSettingsService.Defaults()
+	}
+
+	return channelConfig
 }
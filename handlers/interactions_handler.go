@@ -3,18 +3,49 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/buzzology/slack_bot/service"
 	"github.com/buzzology/slack_bot/service/api"
 	"github.com/buzzology/slack_bot/types"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
+// Identifiers used by the award bounty modals.
+const (
+	awardBountySplitCallbackId = "award_bounty_split"
+	cancelBountyCallbackId     = "cancel_bounty"
+	awardBountyUserBlockId     = "award-bounty-user-id"
+	awardBountyUserActionId    = "award-bounty-user"
+	awardBountyUsersBlockId    = "award-bounty-users-id"
+	awardBountyUsersActionId   = "award-bounty-users"
+	awardBountySharesBlockId   = "award-bounty-shares-id"
+	awardBountySharesActionId  = "award-bounty-shares"
+)
+
+// bountyMetadataSeparator separates the channel and message ids stored in the award and cancel modals' private metadata.
+const bountyMetadataSeparator = ":"
+
+// Identifiers used by the bounty config modal, each input uses its block id as its action id.
+const (
+	bountyConfigCallbackId            = "bounty_config"
+	bountyConfigDailyDecayBlockId     = "bounty-config-daily-decay"
+	bountyConfigDailyIncomeBlockId    = "bounty-config-daily-income"
+	bountyConfigBoostReactionsBlockId = "bounty-config-boost-reactions"
+	bountyConfigTaskCompletedBlockId  = "bounty-config-task-completed-reaction"
+	bountyConfigReleaseBountyBlockId  = "bounty-config-release-bounty-reaction"
+	bountyConfigMinBountyBlockId      = "bounty-config-min-bounty"
+	bountyConfigMaxBountyBlockId      = "bounty-config-max-bounty"
+	bountyConfigMaxBoostBlockId       = "bounty-config-max-boost-per-user"
+	bountyConfigDailySpendCapBlockId  = "bounty-config-daily-spend-cap"
+)
+
 // InteractionsHandler handles and processes events received from slack.
 func (h *SlackBotHandler) InteractionsHandler(w http.ResponseWriter, r *http.Request) {
-	var err error
-
 	logrus.Println("Slack interaction received: ", r.RequestURI)
 
 	ctx := context.Background()
@@ -30,11 +61,37 @@ func (h *SlackBotHandler) InteractionsHandler(w http.ResponseWriter, r *http.Req
 
 	defer r.Body.Close()
 
+	// Some interactions (e.g. invalid modal submissions) need to respond with more than an empty 200.
+	response, err := h.processInteraction(ctx, payload)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to queue slack interaction.")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// processInteraction determines the type of interaction and then handles it. Only validation happens before slack is
+// acknowledged, the rest of the work is queued for the event workers. The response to send back to slack is returned,
+// nil if an empty acknowledgement is enough. An error is only returned if the work couldn't be queued.
+func (h *SlackBotHandler) processInteraction(ctx context.Context, payload string) (interface{}, error) {
+	var err error
+
 	// Initially use a generic unmarshall so that we can determine type.
 	var payloadJson map[string]interface{}
 	if err = json.Unmarshal([]byte(payload), &payloadJson); err != nil {
 		logrus.Errorf("Failed to convert interaction to json: %v, %v", payload, err.Error())
-		return
+		return nil, nil
+	}
+
+	// Api requests should be made with the token of the workspace the interaction came from.
+	if team, ok := payloadJson["team"].(map[string]interface{}); ok {
+		teamId, _ := team["id"].(string)
+		ctx = api.ContextWithTeamId(ctx, teamId)
 	}
 
 	// Check the type of interaction.
@@ -42,59 +99,294 @@ func (h *SlackBotHandler) InteractionsHandler(w http.ResponseWriter, r *http.Req
 	case "message_action":
 		{
 			// This is the initial request to provide a modal.
-			if err = h.handleMessageAction(ctx, payloadJson, r.FormValue("payload")); err != nil {
-				h.log.WithError(err).Error("Failed to process message_action")
-				return
+			if err = h.enqueueInteraction(ctx, func(ctx context.Context) {
+				if err := h.handleMessageAction(ctx, payloadJson, payload); err != nil {
+					h.log.WithError(err).Error("Failed to process message_action")
+				}
+			}); err != nil {
+				return nil, errors.Wrap(err, "failed to queue message_action")
 			}
 		}
 	case "view_submission":
 		{
 			// This is the request we receive when a submission is made from a modal.
-			if err = h.handleViewSubmission(ctx, payloadJson, r.FormValue("payload")); err != nil {
-				h.log.WithError(err).Error("Failed to process view_submission")
-				return
+			response, err := h.handleViewSubmission(ctx, payload)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to queue view_submission")
+			}
+
+			if response != nil {
+				return response, nil
 			}
 		}
 	default:
 		{
 			logrus.Warningf("Unknown interaction type: %v", payloadJson["type"])
-			return
+			return nil, nil
 		}
 	}
+
+	return nil, nil
+}
+
+// enqueueInteraction processes the rest of an interaction on the event workers so that slack is acknowledged first.
+// The job is given a context for the interaction's workspace.
+func (h *SlackBotHandler) enqueueInteraction(ctx context.Context, job func(ctx context.Context)) error {
+	teamId := api.TeamIdFromContext(ctx)
+
+	return h.eventDispatcher.Enqueue(func(ctx context.Context) {
+		job(api.ContextWithTeamId(ctx, teamId))
+	})
 }
 
-// handleViewSubmission is used to handle modal submissions.
+// handleViewSubmission is used to handle modal submissions. A response is returned if the submission is invalid so
+// that the errors can be shown on the modal, valid submissions are queued.
 func (h *SlackBotHandler) handleViewSubmission(
 	ctx context.Context,
-	payloadJson map[string]interface{},
 	rawPayload string,
-) error {
+) (*api.SlackViewSubmissionResponse, error) {
 	// Decode to a slack interaction.
 	interaction := &api.SlackInteraction{}
 	if err := json.Unmarshal([]byte(rawPayload), &interaction); err != nil {
 		logrus.Errorf("Failed to ummarshall interaction payload %v, %v", rawPayload, err.Error())
-		return nil
+		return nil, nil
 	}
 
-	if err := h.awardBounty(ctx, interaction.View.PrivateMetadata, getTargetBountyUserFromInteraction(interaction), interaction.User.Id, ""); err != nil {
-		h.log.WithError(err).WithFields(logrus.Fields{
-			"message_id":         interaction.View.PrivateMetadata,
-			"target_bounty_user": getTargetBountyUserFromInteraction(interaction),
-			"user_id":            interaction.User.Id,
-		}).Error("Unable to award bounty via interaction.")
-		return errors.New("Unable to award bounty via interaction.")
+	if interaction.View != nil && interaction.View.CallbackId == bountyConfigCallbackId {
+		return h.handleBountyConfigSubmission(ctx, interaction)
 	}
 
+	channelId, messageId := parseBountyMetadata(interaction.View)
+
+	if interaction.View != nil && interaction.View.CallbackId == cancelBountyCallbackId {
+		return nil, h.enqueueInteraction(ctx, func(ctx context.Context) {
+			if err := h.cancelBounty(ctx, channelId, messageId, interaction.User.Id); err != nil {
+				h.log.WithError(err).WithFields(logrus.Fields{
+					"message_id": messageId,
+					"channel_id": channelId,
+					"user_id":    interaction.User.Id,
+				}).Error("Unable to cancel bounty via interaction.")
+			}
+		})
+	}
+
+	targetUserIds, shares, validationErrors := getTargetBountyUsersFromInteraction(interaction)
+	if len(validationErrors) > 0 {
+		return &api.SlackViewSubmissionResponse{
+			ResponseAction: "errors",
+			Errors:         validationErrors,
+		}, nil
+	}
+
+	return nil, h.enqueueInteraction(ctx, func(ctx context.Context) {
+		if err := h.awardBounty(ctx, channelId, messageId, targetUserIds, shares, interaction.User.Id, ""); err != nil {
+			h.log.WithError(err).WithFields(logrus.Fields{
+				"message_id":          messageId,
+				"channel_id":          channelId,
+				"target_bounty_users": targetUserIds,
+				"shares":              shares,
+				"user_id":             interaction.User.Id,
+			}).Error("Unable to award bounty via interaction.")
+		}
+	})
+}
+
+// handleBountyConfigSubmission validates the bounty config modal and queues saving the channel's settings.
+func (h *SlackBotHandler) handleBountyConfigSubmission(
+	ctx context.Context,
+	interaction *api.SlackInteraction,
+) (*api.SlackViewSubmissionResponse, error) {
+	settings, validationErrors := getChannelSettingsFromInteraction(interaction)
+	if len(validationErrors) > 0 {
+		return &api.SlackViewSubmissionResponse{
+			ResponseAction: "errors",
+			Errors:         validationErrors,
+		}, nil
+	}
+
+	return nil, h.enqueueInteraction(ctx, func(ctx context.Context) {
+		if err := h.saveChannelSettings(ctx, interaction, settings); err != nil {
+			h.log.WithError(err).WithFields(logrus.Fields{
+				"channel_id": interaction.View.PrivateMetadata,
+				"user_id":    interaction.User.Id,
+			}).Error("Unable to save channel settings via interaction.")
+		}
+	})
+}
+
+// saveChannelSettings saves the settings from the bounty config modal and lets the channel know they've changed.
+func (h *SlackBotHandler) saveChannelSettings(
+	ctx context.Context,
+	interaction *api.SlackInteraction,
+	settings *types.ChannelSettings,
+) error {
+	channelId := interaction.View.PrivateMetadata
+
+	// The modal could have been left open after the user's permissions were changed.
+	canManage, err := h.channelSettingsService.CanManage(ctx, channelId, interaction.User.Id)
+	if err != nil {
+		return err
+	}
+
+	if !canManage {
+		return errors.Errorf("user can't manage channel settings: %v, %v", channelId, interaction.User.Id)
+	}
+
+	settings.ChannelId = channelId
+	settings.UpdatedBy = interaction.User.Id
+	if err = h.channelSettingsService.Save(ctx, settings); err != nil {
+		return err
+	}
+
+	h.apiClient.SendMessage(
+		ctx,
+		&api.SlackPostMessageRequest{
+			Text:    "<@" + interaction.User.Id + "> has updated the bounty settings for this channel, use */bountyemotes* to see the current emotes.",
+			Channel: channelId,
+		})
+
 	return nil
 }
 
-// TODO: Find a better way to do this...
-func getTargetBountyUserFromInteraction(interaction *api.SlackInteraction) string {
-	targetBountyUser := interaction.View.State.Values["award-bounty-user-id"].(map[string]interface{})
-	test3 := targetBountyUser["award-bounty-user"].(map[string]interface{})
-	test4 := test3["selected_user"]
+// getChannelSettingsFromInteraction returns the settings entered on the bounty config modal. Errors are keyed by the
+// block they should be shown against.
+func getChannelSettingsFromInteraction(interaction *api.SlackInteraction) (*types.ChannelSettings, map[string]string) {
+	settings := &types.ChannelSettings{}
+	validationErrors := map[string]string{}
+
+	if interaction.View.State == nil {
+		return settings, nil
+	}
+
+	getValue := func(blockId string) string {
+		value, _ := getViewStateValue(interaction.View.State, blockId, blockId)["value"].(string)
+		return strings.TrimSpace(value)
+	}
+
+	// Empty values are left as nil so that the channel uses the defaults.
+	getNonNegativeInt := func(blockId string) *int {
+		value := getValue(blockId)
+		if value == "" {
+			return nil
+		}
+
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 {
+			validationErrors[blockId] = "Enter a whole number of zero or more."
+			return nil
+		}
+
+		return &i
+	}
+
+	settings.DailyDecay = getNonNegativeInt(bountyConfigDailyDecayBlockId)
+	settings.DailyIncome = getNonNegativeInt(bountyConfigDailyIncomeBlockId)
+	settings.MinBounty = getNonNegativeInt(bountyConfigMinBountyBlockId)
+	settings.MaxBounty = getNonNegativeInt(bountyConfigMaxBountyBlockId)
+	settings.MaxBoostPerUser = getNonNegativeInt(bountyConfigMaxBoostBlockId)
+	settings.DailySpendCap = getNonNegativeInt(bountyConfigDailySpendCapBlockId)
+
+	// Zero means no limit, otherwise the bounties need some room between the minimum and maximum.
+	if settings.MinBounty != nil && settings.MaxBounty != nil && *settings.MaxBounty > 0 && *settings.MinBounty > *settings.MaxBounty {
+		validationErrors[bountyConfigMaxBountyBlockId] = "The maximum bounty can't be less than the minimum."
+	}
+
+	settings.BoostReactions = getValue(bountyConfigBoostReactionsBlockId)
+	if settings.BoostReactions != "" {
+		if _, err := service.ParseBoostReactions(settings.BoostReactions); err != nil {
+			validationErrors[bountyConfigBoostReactionsBlockId] = "Enter emote:value pairs separated by commas, e.g. dollar:1, moneybag:5."
+		}
+	}
+
+	// Reactions can be entered the way they're written in slack, e.g. ":heavy_check_mark:".
+	settings.TaskCompletedByMeReaction = strings.Trim(getValue(bountyConfigTaskCompletedBlockId), ":")
+	settings.ReleaseBountyReaction = strings.Trim(getValue(bountyConfigReleaseBountyBlockId), ":")
+
+	if len(validationErrors) > 0 {
+		return nil, validationErrors
+	}
+
+	return settings, nil
+}
+
+// getTargetBountyUsersFromInteraction returns the users selected on either of the award bounty modals along with any
+// shares the owner has entered. Errors are keyed by the block they should be shown against.
+func getTargetBountyUsersFromInteraction(interaction *api.SlackInteraction) ([]string, []int, map[string]string) {
+	if interaction.View == nil || interaction.View.State == nil {
+		return nil, nil, nil
+	}
+
+	// Single user modal.
+	if selectedUser, ok := getViewStateValue(interaction.View.State, awardBountyUserBlockId, awardBountyUserActionId)["selected_user"].(string); ok {
+		return []string{selectedUser}, nil, nil
+	}
+
+	// Multiple user modal.
+	var targetUserIds []string
+	selectedUsers, _ := getViewStateValue(interaction.View.State, awardBountyUsersBlockId, awardBountyUsersActionId)["selected_users"].([]interface{})
+	for _, selectedUser := range selectedUsers {
+		if userId, ok := selectedUser.(string); ok {
+			targetUserIds = append(targetUserIds, userId)
+		}
+	}
+
+	if len(targetUserIds) == 0 {
+		return nil, nil, map[string]string{awardBountyUsersBlockId: "Pick at least one user to award the bounty to."}
+	}
+
+	// No shares means the bounty is split equally.
+	sharesValue, _ := getViewStateValue(interaction.View.State, awardBountySharesBlockId, awardBountySharesActionId)["value"].(string)
+	if strings.TrimSpace(sharesValue) == "" {
+		return targetUserIds, nil, nil
+	}
+
+	var shares []int
+	for _, shareValue := range strings.Split(sharesValue, ",") {
+		share, err := strconv.Atoi(strings.TrimSpace(shareValue))
+		if err != nil || share < 0 {
+			return nil, nil, map[string]string{awardBountySharesBlockId: "Shares must be whole numbers separated by commas, e.g. 2, 1."}
+		}
+
+		shares = append(shares, share)
+	}
+
+	if len(shares) != len(targetUserIds) {
+		return nil, nil, map[string]string{awardBountySharesBlockId: "Enter one share for each of the selected users."}
+	}
+
+	var totalShares int
+	for _, share := range shares {
+		totalShares += share
+	}
+
+	if totalShares == 0 {
+		return nil, nil, map[string]string{awardBountySharesBlockId: "At least one user must have a share of the bounty."}
+	}
+
+	return targetUserIds, shares, nil
+}
+
+// parseBountyMetadata returns the channel and message ids of the bounty an award or cancel modal was opened for. Modals
+// opened before the channel was included only have the message id.
+func parseBountyMetadata(view *api.SlackView) (string, string) {
+	if view == nil {
+		return "", ""
+	}
+
+	parts := strings.SplitN(view.PrivateMetadata, bountyMetadataSeparator, 2)
+	if len(parts) != 2 {
+		return "", view.PrivateMetadata
+	}
+
+	return parts[0], parts[1]
+}
+
+// getViewStateValue returns the submitted value of an action in a modal, nil if it wasn't submitted.
+func getViewStateValue(state *api.SlackViewState, blockId string, actionId string) map[string]interface{} {
+	block, _ := state.Values[blockId].(map[string]interface{})
+	value, _ := block[actionId].(map[string]interface{})
 
-	return test4.(string)
+	return value
 }
 
 // handleMessageAction is used to handle message actions (requests to show a modal etc).
@@ -110,16 +402,22 @@ func (h *SlackBotHandler) handleMessageAction(
 		return nil
 	}
 
+	// The same checks apply whether the bounty is being awarded or cancelled.
+	action, title := "award", "Award a Bounty"
+	if interaction.CallbackId == cancelBountyCallbackId {
+		action, title = "cancel", "Cancel a Bounty"
+	}
+
 	// Define the generic view that we will display for the modal.
 	slackViewsOpenRequest := &api.SlackViewsOpenRequest{
 		TriggerId: interaction.TriggerId,
 		View: &api.SlackView{
 			Type:            "modal",
 			CallbackId:      interaction.CallbackId,
-			PrivateMetadata: interaction.MessageTs,
+			PrivateMetadata: interaction.Channel.Id + bountyMetadataSeparator + interaction.MessageTs,
 			Title: &api.SlackBlock{
 				Type: "plain_text",
-				Text: "Award a Bounty",
+				Text: title,
 			},
 		},
 	}
@@ -128,6 +426,7 @@ func (h *SlackBotHandler) handleMessageAction(
 	messageBounties, _, err := h.messageBountiesRepo.List(
 		&types.ListMessageBountiesFilter{
 			MessageId: interaction.MessageTs,
+			TeamId:    api.TeamIdFromContext(ctx),
 			ChannelId: interaction.Channel.Id,
 		},
 		1,
@@ -148,7 +447,7 @@ func (h *SlackBotHandler) handleMessageAction(
 				Type: "section",
 				Text: &api.SlackBlockText{
 					Type: "mrkdwn",
-					Text: "There is currently no bounty on this message to award.",
+					Text: "There is currently no bounty on this message to " + action + ".",
 				},
 			},
 		}
@@ -169,7 +468,7 @@ func (h *SlackBotHandler) handleMessageAction(
 				Type: "section",
 				Text: &api.SlackBlockText{
 					Type: "mrkdwn",
-					Text: "Only the message creator can award the bounty.",
+					Text: "Only the message creator can " + action + " the bounty.",
 				},
 			},
 		}
@@ -182,13 +481,18 @@ func (h *SlackBotHandler) handleMessageAction(
 	}
 
 	// Ensure that the bounty hasn't already been awarded.
-	if messageBounty.Status > 1 {
+	if messageBounty.Status != types.MessageBountyStatusOpen {
+		text := "This bounty has already been awarded."
+		if messageBounty.Status != types.MessageBountyStatusAwarded {
+			text = "This bounty is no longer open."
+		}
+
 		slackViewsOpenRequest.View.Blocks = []interface{}{
 			&api.SlackBlock{
 				Type: "section",
 				Text: &api.SlackBlockText{
 					Type: "mrkdwn",
-					Text: "This bounty has already been awarded.",
+					Text: text,
 				},
 			},
 		}
@@ -205,12 +509,113 @@ func (h *SlackBotHandler) handleMessageAction(
 		Type: "plain_text",
 		Text: "Submit",
 	}
+
+	// Show what the bounty is worth and who funded it above the inputs.
+	contributors, err := h.messageBountiesService.Contributors(ctx, messageBounty)
+	if err != nil {
+		return err
+	}
+
+	summary := fmt.Sprintf("This bounty is worth *%v*.", messageBounty.CurrentBounty)
+	if len(contributors) > 0 {
+		summary = fmt.Sprintf("This bounty is worth *%v*, funded by %v.", messageBounty.CurrentBounty, service.FormatContributors(contributors))
+	}
+
+	summaryBlock := &api.SlackBlock{
+		Type: "section",
+		Text: &api.SlackBlockText{
+			Type: "mrkdwn",
+			Text: summary,
+		},
+	}
+
+	// The cancel shortcut asks the owner to confirm before the contributors are refunded.
+	if interaction.CallbackId == cancelBountyCallbackId {
+		slackViewsOpenRequest.View.Submit = &api.SlackBlockSubmit{
+			Type: "plain_text",
+			Text: "Cancel Bounty",
+		}
+		slackViewsOpenRequest.View.Blocks = []interface{}{
+			summaryBlock,
+			&api.SlackBlock{
+				Type: "section",
+				Text: &api.SlackBlockText{
+					Type: "mrkdwn",
+					Text: "Cancelling the bounty refunds each contributor and can't be undone.",
+				},
+			},
+		}
+
+		if _, err := h.apiClient.OpenView(ctx, slackViewsOpenRequest); err != nil {
+			return errors.Wrap(err, "Failed to open the confirmation modal for cancelling a bounty.")
+		}
+
+		return nil
+	}
+
+	// The split shortcut allows the bounty to be shared between several users.
+	if interaction.CallbackId == awardBountySplitCallbackId {
+		slackViewsOpenRequest.View.Title = &api.SlackBlock{
+			Type: "plain_text",
+			Text: "Split a Bounty",
+		}
+		slackViewsOpenRequest.View.Blocks = []interface{}{
+			summaryBlock,
+			&api.SlackBlock{
+				Type:    "input",
+				BlockId: awardBountyUsersBlockId,
+				Element: &api.SlackBlockAccessory{
+					ActionId: awardBountyUsersActionId,
+					Type:     "multi_users_select",
+					Placeholder: &api.SlackBlock{
+						Type: "plain_text",
+						Text: "Select users",
+					},
+				},
+				Label: &api.SlackBlockLabel{
+					Type:  "plain_text",
+					Text:  "Pick the users to split the bounty between.",
+					Emoji: false,
+				},
+			},
+			&api.SlackBlock{
+				Type:     "input",
+				BlockId:  awardBountySharesBlockId,
+				Optional: true,
+				Element: &api.SlackBlockAccessory{
+					ActionId: awardBountySharesActionId,
+					Type:     "plain_text_input",
+					Placeholder: &api.SlackBlock{
+						Type: "plain_text",
+						Text: "e.g. 2, 1",
+					},
+				},
+				Label: &api.SlackBlockLabel{
+					Type:  "plain_text",
+					Text:  "Shares",
+					Emoji: false,
+				},
+				Hint: &api.SlackBlockText{
+					Type: "plain_text",
+					Text: "One share for each user in the order they were picked. Leave empty to split the bounty equally.",
+				},
+			},
+		}
+
+		if _, err := h.apiClient.OpenView(ctx, slackViewsOpenRequest); err != nil {
+			return errors.Wrap(err, "Failed to open the multi user select modal for splitting a bounty.")
+		}
+
+		return nil
+	}
+
 	slackViewsOpenRequest.View.Blocks = []interface{}{
+		summaryBlock,
 		&api.SlackBlock{
 			Type:    "input",
-			BlockId: "award-bounty-user-id",
+			BlockId: awardBountyUserBlockId,
 			Element: &api.SlackBlockAccessory{
-				ActionId: "award-bounty-user",
+				ActionId: awardBountyUserActionId,
 				Type:     "users_select",
 				Placeholder: &api.SlackBlock{
 					Type: "plain_text",
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/buzzology/slack_bot/service/api"
+)
+
+func TestParseBountyMetadata(t *testing.T) {
+	tests := []struct {
+		name          string
+		view          *api.SlackView
+		wantChannelId string
+		wantMessageId string
+	}{
+		{
+			name:          "no view",
+			view:          nil,
+			wantChannelId: "",
+			wantMessageId: "",
+		},
+		{
+			name:          "channel and message",
+			view:          &api.SlackView{PrivateMetadata: "C0123ABC:1531420618.000200"},
+			wantChannelId: "C0123ABC",
+			wantMessageId: "1531420618.000200",
+		},
+		{
+			name:          "message only",
+			view:          &api.SlackView{PrivateMetadata: "1531420618.000200"},
+			wantChannelId: "",
+			wantMessageId: "1531420618.000200",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channelId, messageId := parseBountyMetadata(tt.view)
+			if channelId != tt.wantChannelId || messageId != tt.wantMessageId {
+				t.Errorf("parseBountyMetadata() = %v, %v, want %v, %v", channelId, messageId, tt.wantChannelId, tt.wantMessageId)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// InstallHandler redirects the user to slack so that they can install the bot to their workspace.
+func (h *SlackBotHandler) InstallHandler(w http.ResponseWriter, r *http.Request) {
+	state := h.installationsService.GenerateInstallState(time.Now())
+	http.Redirect(w, r, h.apiClient.AuthorizeUrl(state), http.StatusFound)
+}
+
+// OAuthRedirectHandler completes an install by exchanging the code slack provides for the workspace's bot token.
+func (h *SlackBotHandler) OAuthRedirectHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+
+	// The user cancelled the install or slack rejected it.
+	if oauthError := r.FormValue("error"); oauthError != "" {
+		h.log.WithField("error", oauthError).Warn("Slack install was not completed.")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "The install was not completed: %v", oauthError)
+		return
+	}
+
+	if err := h.installationsService.ValidateInstallState(r.FormValue("state"), time.Now()); err != nil {
+		h.log.WithError(err).Warn("Rejected slack install with an invalid state.")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, "This install link is invalid or has expired, please start the install again.")
+		return
+	}
+
+	accessResponse, err := h.apiClient.OAuthV2Access(ctx, r.FormValue("code"))
+	if err != nil {
+		h.log.WithError(err).Error("Failed to exchange slack oauth code.")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "Failed to complete the install, please try again.")
+		return
+	}
+
+	installation, err := h.installationsService.SaveInstallation(accessResponse)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to save slack installation.")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "Failed to complete the install, please try again.")
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"team_id":      installation.TeamId,
+		"team_name":    installation.TeamName,
+		"installed_by": installation.InstalledBy,
+	}).Info("Slack bounties installed to workspace.")
+
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(w, "Slack Bounties has been installed to %v! See %v to get started.", installation.TeamName, h.config.DocumentationUrl)
+}
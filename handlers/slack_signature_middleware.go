@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	slackSignatureHeader        = "X-Slack-Signature"
+	slackRequestTimestampHeader = "X-Slack-Request-Timestamp"
+	slackSignatureVersion       = "v0"
+
+	// slackSignatureMaxAge is how old a request can be before we treat it as a replay.
+	slackSignatureMaxAge = 5 * time.Minute
+)
+
+// SlackSignatureMiddleware ensures that every request has been signed by slack before it reaches a handler.
+// Docs: https://api.slack.com/authentication/verifying-requests-from-slack
+func SlackSignatureMiddleware(signingSecret string, log logrus.FieldLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Retrieve the raw body, the signature is calculated against it and not the parsed form.
+			body, err := ioutil.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				log.WithError(err).Errorf("Failed to read request body while verifying signature: %v", r.RequestURI)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if err = verifySlackSignature(
+				signingSecret,
+				r.Header.Get(slackSignatureHeader),
+				r.Header.Get(slackRequestTimestampHeader),
+				body,
+				time.Now(),
+			); err != nil {
+				log.WithError(err).WithFields(logrus.Fields{
+					"uri":         r.RequestURI,
+					"remote_addr": r.RemoteAddr,
+				}).Warn("Rejected request with an invalid slack signature.")
+
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte("Unauthorized: " + err.Error()))
+				return
+			}
+
+			// Restore the body so that handlers can read it as normal.
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// verifySlackSignature checks that the signature was generated by slack using our signing secret and that it is recent.
+func verifySlackSignature(
+	signingSecret string,
+	signature string,
+	timestamp string,
+	body []byte,
+	now time.Time,
+) error {
+	if signingSecret == "" {
+		return errors.New("no signing secret has been configured")
+	}
+
+	if signature == "" || timestamp == "" {
+		return errors.New("missing slack signature headers")
+	}
+
+	// Reject anything outside of the replay window.
+	requestTime, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid slack request timestamp")
+	}
+
+	if math.Abs(now.Sub(time.Unix(requestTime, 0)).Seconds()) > slackSignatureMaxAge.Seconds() {
+		return errors.New("slack request timestamp is outside of the allowed window")
+	}
+
+	// Compute the expected signature and compare in constant time.
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte(slackSignatureVersion + ":" + timestamp + ":"))
+	mac.Write(body)
+	expected := slackSignatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("slack signature does not match")
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestVerifySlackSignature(t *testing.T) {
+	const secret = "8f742231b10e8888abcd99yyyzzz85a5"
+	body := []byte("token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&command=%2Fweather&text=94070")
+	now := time.Unix(1531420618, 0)
+	timestamp := fmt.Sprint(now.Unix())
+
+	sign := func(secret string, timestamp string, body []byte) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("v0:" + timestamp + ":"))
+		mac.Write(body)
+		return "v0=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		now       time.Time
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			secret:    secret,
+			signature: sign(secret, timestamp, body),
+			timestamp: timestamp,
+			body:      body,
+			now:       now,
+		},
+		{
+			name:      "valid at the edge of the window",
+			secret:    secret,
+			signature: sign(secret, timestamp, body),
+			timestamp: timestamp,
+			body:      body,
+			now:       now.Add(slackSignatureMaxAge),
+		},
+		{
+			name:      "no signing secret",
+			secret:    "",
+			signature: sign("", timestamp, body),
+			timestamp: timestamp,
+			body:      body,
+			now:       now,
+			wantErr:   true,
+		},
+		{
+			name:      "missing signature",
+			secret:    secret,
+			timestamp: timestamp,
+			body:      body,
+			now:       now,
+			wantErr:   true,
+		},
+		{
+			name:      "missing timestamp",
+			secret:    secret,
+			signature: sign(secret, timestamp, body),
+			body:      body,
+			now:       now,
+			wantErr:   true,
+		},
+		{
+			name:      "invalid timestamp",
+			secret:    secret,
+			signature: sign(secret, "yesterday", body),
+			timestamp: "yesterday",
+			body:      body,
+			now:       now,
+			wantErr:   true,
+		},
+		{
+			name:      "too old",
+			secret:    secret,
+			signature: sign(secret, timestamp, body),
+			timestamp: timestamp,
+			body:      body,
+			now:       now.Add(slackSignatureMaxAge + time.Second),
+			wantErr:   true,
+		},
+		{
+			name:      "from the future",
+			secret:    secret,
+			signature: sign(secret, timestamp, body),
+			timestamp: timestamp,
+			body:      body,
+			now:       now.Add(-slackSignatureMaxAge - time.Second),
+			wantErr:   true,
+		},
+		{
+			name:      "signed with another secret",
+			secret:    secret,
+			signature: sign("another secret", timestamp, body),
+			timestamp: timestamp,
+			body:      body,
+			now:       now,
+			wantErr:   true,
+		},
+		{
+			name:      "body changed",
+			secret:    secret,
+			signature: sign(secret, timestamp, body),
+			timestamp: timestamp,
+			body:      []byte(string(body) + "1"),
+			now:       now,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySlackSignature(tt.secret, tt.signature, tt.timestamp, tt.body, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifySlackSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
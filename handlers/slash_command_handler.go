@@ -5,88 +5,145 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/buzzology/slack_bot/db"
+	"github.com/buzzology/slack_bot/service"
 	"github.com/buzzology/slack_bot/service/api"
 	"github.com/buzzology/slack_bot/types"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
+// errUnknownSlashCommand is returned when we receive a command that we don't handle.
+var errUnknownSlashCommand = errors.New("unrecognised slack command")
+
+// tipCommandPattern matches "@user <amount> [note]", slack escapes the user as <@U123|name>.
+var tipCommandPattern = regexp.MustCompile(`^<@([A-Z0-9]+)(?:\|[^>]*)?>\s+(\d+)\s*(.*)$`)
+
 // SlashCommandHandler handles and processes events received from slack.
 func (h *SlackBotHandler) SlashCommandHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+
+	r.ParseForm()
+
+	slashCommand := &api.SlackSlashCommand{
+		Command:     r.FormValue("command"),
+		Text:        r.FormValue("text"),
+		UserId:      r.FormValue("user_id"),
+		UserName:    r.FormValue("user_name"),
+		ChannelId:   r.FormValue("channel_id"),
+		TeamId:      r.FormValue("team_id"),
+		TriggerId:   r.FormValue("trigger_id"),
+		ResponseUrl: r.FormValue("response_url"),
+	}
+
+	slackBlocks, err := h.processSlashCommand(ctx, slashCommand)
+	if err == errUnknownSlashCommand {
+		w.Write([]byte("Unknown command"))
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Some commands (e.g. opening a modal) don't have anything to respond with.
+	if slackBlocks == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	message, err := json.Marshal(slackBlocks)
+	if err != nil {
+		h.log.WithError(err).WithFields(
+			logrus.Fields{
+				"command":   slashCommand.Command,
+				"user":      slashCommand.UserId,
+				"user_name": slashCommand.UserName,
+			}).Error("Failed to serialize slack blocks.")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("%q", message)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(message)
+}
+
+// processSlashCommand runs the slash command and returns the blocks to respond with.
+func (h *SlackBotHandler) processSlashCommand(
+	ctx context.Context,
+	slashCommand *api.SlackSlashCommand,
+) (*api.SlackBlocks, error) {
 	var (
 		slackBlocks *api.SlackBlocks
 		err         error
 	)
 
-	ctx := context.Background()
-
-	r.ParseForm()
+	// Api requests should be made with the token of the workspace the command came from.
+	ctx = api.ContextWithTeamId(ctx, slashCommand.TeamId)
 
 	// Check the command type.
-	switch r.FormValue("command") {
+	switch slashCommand.Command {
 	case "/bountyme":
 		{
-			slackBlocks, err = h.handleSlashCommandMe(ctx, r.FormValue("user_id"), r.FormValue("channel_id"))
+			slackBlocks, err = h.handleSlashCommandMe(ctx, slashCommand.UserId, slashCommand.ChannelId)
 		}
 	case "/bountyemotes":
 		{
-			slackBlocks, err = h.handleSlashCommandEmotes(ctx)
+			slackBlocks, err = h.handleSlashCommandEmotes(ctx, slashCommand.ChannelId)
 		}
 	case "/bountydaily":
 		{
-			slackBlocks, err = h.handleSlashCommandDailyLeaders(ctx, r.FormValue("channel_id"))
+			slackBlocks, err = h.handleSlashCommandDailyLeaders(ctx, slashCommand.ChannelId)
 		}
 	case "/bountyweekly":
 		{
-			slackBlocks, err = h.handleSlashCommandWeeklyLeaders(ctx, r.FormValue("channel_id"))
+			slackBlocks, err = h.handleSlashCommandWeeklyLeaders(ctx, slashCommand.ChannelId)
 		}
 	case "/bountyyearly":
 		{
-			slackBlocks, err = h.handleSlashCommandYearlyLeaders(ctx, r.FormValue("channel_id"))
+			slackBlocks, err = h.handleSlashCommandYearlyLeaders(ctx, slashCommand.ChannelId)
 		}
 	case "/bountyalltime":
 		{
-			slackBlocks, err = h.handleSlashCommandAllTimeLeaders(ctx, r.FormValue("channel_id"))
+			slackBlocks, err = h.handleSlashCommandAllTimeLeaders(ctx, slashCommand.ChannelId)
+		}
+	case "/bountytip":
+		{
+			slackBlocks, err = h.handleSlashCommandTip(ctx, slashCommand)
+		}
+	case "/bountyseason":
+		{
+			slackBlocks, err = h.handleSlashCommandSeason(ctx, slashCommand)
 		}
 	case "/bountyconfig":
 		{
-
+			slackBlocks, err = h.handleSlashCommandConfig(ctx, slashCommand)
 		}
 	default:
-		h.log.Warnf("unrecognised slack command: %v", r.FormValue("command"))
-		w.Write([]byte("Unknown command"))
+		h.log.Warnf("unrecognised slack command: %v", slashCommand.Command)
+		return nil, errUnknownSlashCommand
 	}
 
 	if err != nil {
 		h.log.WithError(err).WithFields(
 			logrus.Fields{
-				"command":   r.FormValue("command"),
-				"user":      r.FormValue("user_id"),
-				"user_name": r.FormValue("user_name"),
+				"command":   slashCommand.Command,
+				"user":      slashCommand.UserId,
+				"user_name": slashCommand.UserName,
 			}).Error("Unable to process slash command.")
 
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	message, err := json.Marshal(slackBlocks)
-	if err != nil {
-		h.log.WithError(err).WithFields(
-			logrus.Fields{
-				"command":   r.FormValue("command"),
-				"user":      r.FormValue("user_id"),
-				"user_name": r.FormValue("user_name"),
-			}).Error("Failed to serialize slack blocks.")
-
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 
-	fmt.Printf("%q", message)
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(message)
+	return slackBlocks, nil
 }
 
 // handleSlashCommandDailyLeaders the current leaderboard for today.
@@ -124,11 +181,14 @@ func (h *SlackBotHandler) handleSlashCommandAllTimeLeaders(
 // handleSlashCommandEmotes shows the current user what each emote does.
 func (h *SlackBotHandler) handleSlashCommandEmotes(
 	ctx context.Context,
+	channelId string,
 ) (*api.SlackBlocks, error) {
+	channelConfig := h.getChannelConfig(ctx, channelId)
+
 	bountyReactionFields := api.SlackFieldsBlock{
 		Type: "section",
 	}
-	for _, boostReactionSetting := range h.config.BoostReactions {
+	for _, boostReactionSetting := range channelConfig.BoostReactions {
 		bountyReactionFields.Fields = append(
 			bountyReactionFields.Fields,
 			api.SlackBlock{
@@ -163,7 +223,7 @@ func (h *SlackBotHandler) handleSlashCommandEmotes(
 					},
 					api.SlackBlock{
 						Type: "plain_text",
-						Text: fmt.Sprintf(":%v:", h.config.ReleaseBountyReaction),
+						Text: fmt.Sprintf(":%v:", channelConfig.ReleaseBountyReaction),
 					},
 					api.SlackBlock{
 						Type: "mrkdwn",
@@ -171,7 +231,7 @@ func (h *SlackBotHandler) handleSlashCommandEmotes(
 					},
 					api.SlackBlock{
 						Type: "plain_text",
-						Text: fmt.Sprintf(":%v:", h.config.TaskCompletedByMeReaction),
+						Text: fmt.Sprintf(":%v:", channelConfig.TaskCompletedByMeReaction),
 					},
 				},
 			},
@@ -180,6 +240,248 @@ func (h *SlackBotHandler) handleSlashCommandEmotes(
 	}, nil
 }
 
+// handleSlashCommandTip sends points from the current user to another user in the channel.
+func (h *SlackBotHandler) handleSlashCommandTip(
+	ctx context.Context,
+	slashCommand *api.SlackSlashCommand,
+) (*api.SlackBlocks, error) {
+	matches := tipCommandPattern.FindStringSubmatch(strings.TrimSpace(slashCommand.Text))
+	if matches == nil {
+		return newSlashCommandTextResponse("Usage: */bountytip @user <amount> [note]*, e.g. /bountytip @jane 2 thanks for the help!"), nil
+	}
+
+	toUserId, note := matches[1], strings.TrimSpace(matches[3])
+	amount, err := strconv.Atoi(matches[2])
+	if err != nil || amount <= 0 {
+		return newSlashCommandTextResponse("Tips must be a whole number of points greater than zero."), nil
+	}
+
+	if toUserId == slashCommand.UserId {
+		return newSlashCommandTextResponse("You can't tip yourself."), nil
+	}
+
+	// Only real users can be tipped, otherwise the points are lost to an account nobody can use.
+	toUser, err := h.apiClient.GetUserInfo(ctx, toUserId)
+	if err != nil {
+		h.log.WithError(err).WithField("user_id", toUserId).Warn("Failed to retrieve the user being tipped.")
+		return newSlashCommandTextResponse("<@" + toUserId + "> couldn't be found."), nil
+	}
+
+	if toUser.IsBot || toUser.Id == "USLACKBOT" {
+		return newSlashCommandTextResponse("Bots can't be tipped."), nil
+	}
+
+	if toUser.Deleted {
+		return newSlashCommandTextResponse("<@" + toUserId + "> has been deactivated."), nil
+	}
+
+	from, err := h.channelAccountsService.GetOrCreate(ctx, slashCommand.UserId, slashCommand.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := h.channelAccountsService.GetOrCreate(ctx, toUserId, slashCommand.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users that have left the channel can't be tipped.
+	if to.Frozen {
+		return newSlashCommandTextResponse("<@" + toUserId + "> is no longer in this channel."), nil
+	}
+
+	tip, err := h.tipsService.Tip(ctx, slashCommand.ChannelId, from, to, amount, note)
+	switch {
+	case err == db.ErrInsufficientFunds:
+		return newSlashCommandTextResponse(fmt.Sprintf("You don't have enough points to tip %v, your balance is %v.", amount, from.Balance)), nil
+	case err == service.ErrTipLimitExceeded:
+		remaining, err := h.tipsService.RemainingTipLimit(ctx, slashCommand.ChannelId, from)
+		if err != nil {
+			return nil, err
+		}
+
+		return newSlashCommandTextResponse(fmt.Sprintf("That would take you over the daily tip limit, you can tip %v more today.", remaining)), nil
+	case err == service.ErrSelfTip:
+		return newSlashCommandTextResponse("You can't tip yourself."), nil
+	case err != nil:
+		return nil, err
+	}
+
+	text := fmt.Sprintf("<@%v> has tipped <@%v> %v.", tip.FromUserId, tip.ToUserId, tip.Amount)
+	if tip.Note != "" {
+		text = fmt.Sprintf("<@%v> has tipped <@%v> %v: %v", tip.FromUserId, tip.ToUserId, tip.Amount, tip.Note)
+	}
+
+	// Direct messages are sent to the recipient's user id.
+	channel := slashCommand.ChannelId
+	if h.config.TipAnnouncements == service.TipAnnouncementsDm {
+		channel = tip.ToUserId
+		text += fmt.Sprintf(" (in <#%v>)", slashCommand.ChannelId)
+	}
+
+	h.apiClient.SendMessage(
+		ctx,
+		&api.SlackPostMessageRequest{
+			Text:    text,
+			Channel: channel,
+		})
+
+	return newSlashCommandTextResponse(fmt.Sprintf("You've tipped <@%v> %v.", tip.ToUserId, tip.Amount)), nil
+}
+
+// newSlashCommandTextResponse returns a single section of text to respond to a slash command with.
+func newSlashCommandTextResponse(text string) *api.SlackBlocks {
+	return &api.SlackBlocks{
+		Blocks: []interface{}{
+			&api.SlackBlock{
+				Type: "section",
+				Text: &api.SlackBlockText{
+					Type: "mrkdwn",
+					Text: text,
+				},
+			},
+		},
+	}
+}
+
+// handleSlashCommandSeason shows the current season's standings, or the final standings of the named season.
+func (h *SlackBotHandler) handleSlashCommandSeason(
+	ctx context.Context,
+	slashCommand *api.SlackSlashCommand,
+) (*api.SlackBlocks, error) {
+	season := strings.TrimSpace(slashCommand.Text)
+	if season == "" {
+		return h.seasonsService.Summary(ctx, slashCommand.ChannelId)
+	}
+
+	return h.seasonsService.Results(ctx, slashCommand.ChannelId, season)
+}
+
+// handleSlashCommandConfig opens a modal that allows a channel manager to change the channel's settings. Anyone else is
+// told that they can't.
+func (h *SlackBotHandler) handleSlashCommandConfig(
+	ctx context.Context,
+	slashCommand *api.SlackSlashCommand,
+) (*api.SlackBlocks, error) {
+	canManage, err := h.channelSettingsService.CanManage(ctx, slashCommand.ChannelId, slashCommand.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canManage {
+		return newSlashCommandTextResponse("Only channel managers can change the bounty settings for this channel."), nil
+	}
+
+	settings, err := h.channelSettingsService.GetOverrides(ctx, slashCommand.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings == nil {
+		settings = &types.ChannelSettings{}
+	}
+
+	var dailyDecay, dailyIncome string
+	if settings.DailyDecay != nil {
+		dailyDecay = fmt.Sprint(*settings.DailyDecay)
+	}
+
+	if settings.DailyIncome != nil {
+		dailyIncome = fmt.Sprint(*settings.DailyIncome)
+	}
+
+	// Limits of zero are shown as "none" so that it's clear there isn't one.
+	formatLimit := func(limit int) string {
+		if limit == 0 {
+			return "0 (none)"
+		}
+
+		return fmt.Sprint(limit)
+	}
+
+	formatLimitOverride := func(limit *int) string {
+		if limit == nil {
+			return ""
+		}
+
+		return fmt.Sprint(*limit)
+	}
+
+	defaults := h.channelSettingsService.Defaults()
+
+	if _, err = h.apiClient.OpenView(ctx, &api.SlackViewsOpenRequest{
+		TriggerId: slashCommand.TriggerId,
+		View: &api.SlackView{
+			Type:            "modal",
+			CallbackId:      bountyConfigCallbackId,
+			PrivateMetadata: slashCommand.ChannelId,
+			Title: &api.SlackBlock{
+				Type: "plain_text",
+				Text: "Bounty Settings",
+			},
+			Submit: &api.SlackBlockSubmit{
+				Type: "plain_text",
+				Text: "Save",
+			},
+			Blocks: []interface{}{
+				&api.SlackBlock{
+					Type: "section",
+					Text: &api.SlackBlockText{
+						Type: "mrkdwn",
+						Text: "These settings only apply to <#" + slashCommand.ChannelId + ">. Leave a setting empty to use the default.",
+					},
+				},
+				newConfigInputBlock(bountyConfigDailyDecayBlockId, "Daily decay", dailyDecay, fmt.Sprint(defaults.DailyDecay)),
+				newConfigInputBlock(bountyConfigDailyIncomeBlockId, "Daily income", dailyIncome, fmt.Sprint(defaults.DailyIncome)),
+				newConfigInputBlock(bountyConfigBoostReactionsBlockId, "Boost reactions", settings.BoostReactions, service.FormatBoostReactions(defaults.BoostReactions)),
+				newConfigInputBlock(bountyConfigTaskCompletedBlockId, "Task completed reaction", settings.TaskCompletedByMeReaction, defaults.TaskCompletedByMeReaction),
+				newConfigInputBlock(bountyConfigReleaseBountyBlockId, "Award bounty reaction", settings.ReleaseBountyReaction, defaults.ReleaseBountyReaction),
+				newConfigInputBlock(bountyConfigMinBountyBlockId, "Minimum bounty", formatLimitOverride(settings.MinBounty), formatLimit(defaults.MinBounty)),
+				newConfigInputBlock(bountyConfigMaxBountyBlockId, "Maximum bounty", formatLimitOverride(settings.MaxBounty), formatLimit(defaults.MaxBounty)),
+				newConfigInputBlock(bountyConfigMaxBoostBlockId, "Maximum boost per user on each bounty", formatLimitOverride(settings.MaxBoostPerUser), formatLimit(defaults.MaxBoostPerUser)),
+				newConfigInputBlock(bountyConfigDailySpendCapBlockId, "Daily spend cap per user", formatLimitOverride(settings.DailySpendCap), formatLimit(defaults.DailySpendCap)),
+			},
+		},
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to open the bounty config modal")
+	}
+
+	return nil, nil
+}
+
+// newConfigInputBlock returns an optional text input for one of the channel's settings.
+func newConfigInputBlock(blockId string, label string, value string, defaultValue string) *api.SlackBlock {
+	element := &api.SlackBlockAccessory{
+		ActionId:     blockId,
+		Type:         "plain_text_input",
+		InitialValue: value,
+	}
+
+	// Slack rejects empty placeholders.
+	if defaultValue != "" {
+		element.Placeholder = &api.SlackBlock{
+			Type: "plain_text",
+			Text: defaultValue,
+		}
+	}
+
+	return &api.SlackBlock{
+		Type:     "input",
+		BlockId:  blockId,
+		Optional: true,
+		Element:  element,
+		Label: &api.SlackBlockLabel{
+			Type:  "plain_text",
+			Text:  label,
+			Emoji: false,
+		},
+		Hint: &api.SlackBlockText{
+			Type: "plain_text",
+			Text: "Default: " + defaultValue,
+		},
+	}
+}
+
 // handleSlashCommandMe displays stats for the current user.
 func (h *SlackBotHandler) handleSlashCommandMe(
 	ctx context.Context,
@@ -189,8 +491,9 @@ func (h *SlackBotHandler) handleSlashCommandMe(
 	// Retrieve the user's account.
 	channelAccounts, _, err := h.channelAccountsRepo.List(
 		&types.ListChannelAccountsFilter{
+			TeamId:    api.TeamIdFromContext(ctx),
 			UserId:    userId,
-			ChannelId: channelId,
+			ChannelId: h.config.AccountChannelId(channelId),
 		},
 		1,
 		"",
@@ -208,13 +511,24 @@ func (h *SlackBotHandler) handleSlashCommandMe(
 		channelAccount = &types.ChannelAccount{}
 	}
 
+	// Achievements are earned for the whole workspace when it uses workspace wallets.
+	achievementCounts, err := h.achievementsService.Earned(ctx, channelId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	achievements := service.FormatAchievements(achievementCounts)
+	if achievements == "" {
+		achievements = "_No achievements yet._"
+	}
+
 	return &api.SlackBlocks{
 		Blocks: []interface{}{
 			&api.SlackBlock{
 				Type: "header",
 				Text: api.SlackBlock{
 					Type: "plain_text",
-					Text: "Your Bounty :" + h.config.TaskCompletedByMeReaction + ":",
+					Text: "Your Bounty :" + h.getChannelConfig(ctx, channelId).TaskCompletedByMeReaction + ":",
 				},
 			},
 			&api.SlackBlockRawType{
@@ -307,6 +621,13 @@ func (h *SlackBotHandler) handleSlashCommandMe(
 					},
 				},
 			},
+			&api.SlackBlock{
+				Type: "section",
+				Text: &api.SlackBlockText{
+					Type: "mrkdwn",
+					Text: "*Achievements*\n" + achievements,
+				},
+			},
 		},
 	}, nil
 }
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/buzzology/slack_bot/service/api"
+	"github.com/pkg/errors"
+)
+
+// SocketModeHandler routes envelopes received over socket mode through the same logic as the http endpoints.
+func (h *SlackBotHandler) SocketModeHandler(ctx context.Context, envelope *api.SlackSocketModeEnvelope) (interface{}, error) {
+	switch envelope.Type {
+	case "events_api":
+		eventEnvelope := &api.SlackEventEnvelope{}
+		if err := json.Unmarshal(envelope.Payload, eventEnvelope); err != nil {
+			// Nothing will change on a redelivery so acknowledge it anyway.
+			h.log.WithError(err).Errorf("Failed to convert socket mode event to json: %v", string(envelope.Payload))
+			return nil, nil
+		}
+
+		if eventEnvelope.Type != "event_callback" {
+			h.log.Warningf("Unknown event type: %v", eventEnvelope.Type)
+			return nil, nil
+		}
+
+		var retryNum string
+		if envelope.RetryAttempt > 0 {
+			retryNum = fmt.Sprint(envelope.RetryAttempt)
+		}
+
+		if err := h.queueEventCallback(eventEnvelope, retryNum, envelope.RetryReason); err != nil {
+			return nil, errors.Wrap(err, "failed to queue socket mode event")
+		}
+
+		return nil, nil
+	case "slash_commands":
+		slashCommand := &api.SlackSlashCommand{}
+		if err := json.Unmarshal(envelope.Payload, slashCommand); err != nil {
+			h.log.WithError(err).Errorf("Failed to unmarshal socket mode slash command: %v", string(envelope.Payload))
+			return nil, nil
+		}
+
+		slackBlocks, err := h.processSlashCommand(ctx, slashCommand)
+		if err == errUnknownSlashCommand {
+			return map[string]string{"text": "Unknown command"}, nil
+		}
+
+		if err != nil {
+			// The user is waiting on a response so there's no point in slack redelivering this.
+			return map[string]string{"text": "Something went wrong, please try again."}, nil
+		}
+
+		if slackBlocks == nil {
+			return nil, nil
+		}
+
+		return slackBlocks, nil
+	case "interactive":
+		// Only validation happens before the acknowledgement, if the rest can't be queued slack will redeliver it.
+		return h.processInteraction(ctx, string(envelope.Payload))
+	default:
+		h.log.Warningf("Unknown socket mode envelope type: %v", envelope.Type)
+		return nil, nil
+	}
+}
@@ -2,22 +2,31 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
 
+	"github.com/buzzology/slack_bot/db"
+	"github.com/buzzology/slack_bot/service"
 	"github.com/buzzology/slack_bot/service/api"
 	"github.com/buzzology/slack_bot/types"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	slackRetryNumHeader    = "X-Slack-Retry-Num"
+	slackRetryReasonHeader = "X-Slack-Retry-Reason"
+)
+
+// errBoostLimitExceeded rolls back a boost that breaks one of the channel's limits once it has been applied.
+var errBoostLimitExceeded = errors.New("boost exceeds the channel's bounty limits")
+
 // WebhookHandler handles and processes events received from slack.
 func (h *SlackBotHandler) WebhookHandler(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-
 	logrus.Println("Slack webhook received: ", r.RequestURI)
 
 	defer r.Body.Close()
@@ -30,75 +39,406 @@ func (h *SlackBotHandler) WebhookHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var event map[string]interface{}
-	if err = json.Unmarshal(body, &event); err != nil {
+	envelope := &api.SlackEventEnvelope{}
+	if err = json.Unmarshal(body, envelope); err != nil {
 		logrus.Errorf("Failed to convert event to json: %v, %v", string(body), err.Error())
+		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	// TODO: We need to verify the slack signature etc.
-
-	switch event["type"] {
+	switch envelope.Type {
 	case "url_verification":
-		w.WriteHeader(http.StatusOK)
 		w.Header().Add("Content-type", "text/plain")
-		w.Write([]byte(event["challenge"].(string)))
-	case "event_callback":
 		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(envelope.Challenge))
+	case "event_callback":
+		if err = h.queueEventCallback(
+			envelope,
+			r.Header.Get(slackRetryNumHeader),
+			r.Header.Get(slackRetryReasonHeader),
+		); err != nil {
+			h.log.WithError(err).WithField("event", envelope.EventId).Error("Failed to queue event, slack will redeliver it.")
+
+			if errors.Cause(err) == service.ErrEventQueueFull || errors.Cause(err) == service.ErrEventDispatcherStopped {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			return
+		}
 
-		// Retrieve the event body
-		h.handleEventCallback(ctx, event, body)
+		w.WriteHeader(http.StatusOK)
 		return
 	default:
-		logrus.Warningf("Unknown event type: %v", event["type"])
+		logrus.Warningf("Unknown event type: %v", envelope.Type)
 		return
 	}
+}
 
-	w.WriteHeader(http.StatusOK)
+// queueEventCallback hands the event to a worker so that slack can be acknowledged straight away. If an error is returned
+// the event should not be acknowledged so that slack will redeliver it.
+func (h *SlackBotHandler) queueEventCallback(
+	envelope *api.SlackEventEnvelope,
+	retryNum string,
+	retryReason string,
+) error {
+	// Slack redelivers events when we're slow to respond, only the first delivery should have side effects.
+	alreadyProcessed, err := h.markEventProcessed(envelope, retryNum, retryReason)
+	if err != nil {
+		return errors.Wrap(err, "failed to check if event has already been processed")
+	}
+
+	if alreadyProcessed {
+		h.log.WithFields(logrus.Fields{
+			"event":        envelope.EventId,
+			"retry_num":    retryNum,
+			"retry_reason": retryReason,
+		}).Info("Acknowledged an event that has already been processed.")
+		return nil
+	}
+
+	if err = h.eventDispatcher.Enqueue(func(ctx context.Context) {
+		h.handleEventCallback(ctx, envelope)
+	}); err != nil {
+		// Forget the event so that the redelivery isn't skipped.
+		if deleteErr := h.processedEventsRepo.Delete(envelope.EventId); deleteErr != nil {
+			h.log.WithError(deleteErr).WithField("event", envelope.EventId).Error("Failed to remove processed event after it could not be queued.")
+		}
+
+		return err
+	}
+
+	return nil
 }
 
-func (h *SlackBotHandler) handleEventCallback(ctx context.Context, event map[string]interface{}, rawEvent []byte) {
-	// Check the event type.
-	switch event["event"].(map[string]interface{})["type"] {
-	case "reaction_added":
+// markEventProcessed records the event so that any redeliveries can be acknowledged without side effects.
+func (h *SlackBotHandler) markEventProcessed(envelope *api.SlackEventEnvelope, retryNum string, retryReason string) (bool, error) {
+	if envelope.EventId == "" {
+		return false, errors.New("event_callback did not include an event_id")
+	}
 
-		reactionAddedEvent := &api.SlackReactionAddedEvent{}
-		if err := json.Unmarshal(rawEvent, &reactionAddedEvent); err != nil {
-			logrus.Errorf("Failed to ummarshall reaction_added event: %v, %v", string(rawEvent), err.Error())
+	// The type is only recorded for reference so a malformed event is still marked (and then rejected by the registry).
+	var eventType string
+	if header, err := envelope.Header(); err == nil {
+		eventType = header.Type
+	}
+
+	// A retry we haven't seen before still needs to be processed (e.g. we went down before recording it).
+	if retryNum != "" {
+		h.log.WithFields(logrus.Fields{
+			"event":        envelope.EventId,
+			"retry_num":    retryNum,
+			"retry_reason": retryReason,
+		}).Info("Slack event redelivery received.")
+	}
+
+	return h.processedEventsRepo.MarkProcessed(
+		&types.ProcessedEvent{
+			EventId:   envelope.EventId,
+			EventType: eventType,
+			TeamId:    envelope.TeamId,
+		},
+	)
+}
+
+// handleEventCallback passes the event to the handler registered for its type.
+func (h *SlackBotHandler) handleEventCallback(ctx context.Context, envelope *api.SlackEventEnvelope) {
+	// Api requests and new rows should belong to the workspace the event came from.
+	ctx = api.ContextWithTeamId(ctx, envelope.TeamId)
+
+	if err := h.eventRegistry.Dispatch(ctx, envelope); err != nil {
+		if errors.Cause(err) == api.ErrUnhandledEvent {
+			h.log.WithField("event", envelope.EventId).Infof("Skipping unhandled event: %v", err)
 			return
 		}
 
-		if err := h.handleReactionAddedEvent(ctx, reactionAddedEvent); err != nil {
-			h.log.WithError(err).WithFields(
-				logrus.Fields{
-					"event":    reactionAddedEvent.EventID,
-					"reaction": reactionAddedEvent.Event.Reaction,
-					"user":     reactionAddedEvent.Event.User,
-				}).Error("Unable to process reaction added event.")
+		h.log.WithError(err).WithField("event", envelope.EventId).Error("Unable to process event.")
+	}
+}
 
-			return
+// registerEventHandlers adds a handler for each of the event types we subscribe to.
+func (h *SlackBotHandler) registerEventHandlers() {
+	h.eventRegistry.Register(
+		"reaction_added",
+		"",
+		api.DecodeSlackReactionAddedEvent,
+		func(ctx context.Context, decoded interface{}) error {
+			event, ok := decoded.(*api.SlackReactionAddedEvent)
+			if !ok {
+				return fmt.Errorf("unexpected reaction_added event type: %T", decoded)
+			}
+
+			if err := h.handleReactionAddedEvent(ctx, event); err != nil {
+				h.log.WithError(err).WithFields(
+					logrus.Fields{
+						"event":    event.EventID,
+						"reaction": event.Event.Reaction,
+						"user":     event.Event.User,
+					}).Error("Unable to process reaction added event.")
+			}
+
+			return nil
+		},
+	)
+
+	h.eventRegistry.Register(
+		"reaction_removed",
+		"",
+		api.DecodeSlackReactionRemovedEvent,
+		func(ctx context.Context, decoded interface{}) error {
+			event, ok := decoded.(*api.SlackReactionRemovedEvent)
+			if !ok {
+				return fmt.Errorf("unexpected reaction_removed event type: %T", decoded)
+			}
+
+			if err := h.handleReactionRemovedEvent(ctx, event); err != nil {
+				h.log.WithError(err).WithFields(
+					logrus.Fields{
+						"event":    event.EventID,
+						"reaction": event.Event.Reaction,
+						"user":     event.Event.User,
+					}).Error("Unable to process reaction removed event.")
+			}
+
+			return nil
+		},
+	)
+
+	h.eventRegistry.Register(
+		"message",
+		"message_deleted",
+		api.DecodeSlackMessageDeletedEvent,
+		func(ctx context.Context, decoded interface{}) error {
+			event, ok := decoded.(*api.SlackMessageDeletedEvent)
+			if !ok {
+				return fmt.Errorf("unexpected message_deleted event type: %T", decoded)
+			}
+
+			if err := h.handleMessageDeletedEvent(ctx, event); err != nil {
+				h.log.WithError(err).WithFields(
+					logrus.Fields{
+						"event":      event.EventID,
+						"message_id": event.Event.DeletedTs,
+						"channel_id": event.Event.Channel,
+					}).Error("Unable to process message deleted event.")
+			}
+
+			return nil
+		},
+	)
+
+	h.eventRegistry.Register(
+		"member_left_channel",
+		"",
+		api.DecodeSlackMemberChannelEvent,
+		func(ctx context.Context, decoded interface{}) error {
+			event, ok := decoded.(*api.SlackMemberChannelEvent)
+			if !ok {
+				return fmt.Errorf("unexpected member_left_channel event type: %T", decoded)
+			}
+
+			if err := h.handleMemberLeftChannelEvent(ctx, event); err != nil {
+				h.log.WithError(err).WithFields(
+					logrus.Fields{
+						"event":      event.EventID,
+						"user":       event.Event.User,
+						"channel_id": event.Event.Channel,
+					}).Error("Unable to process member left channel event.")
+			}
+
+			return nil
+		},
+	)
+
+	h.eventRegistry.Register(
+		"member_joined_channel",
+		"",
+		api.DecodeSlackMemberChannelEvent,
+		func(ctx context.Context, decoded interface{}) error {
+			event, ok := decoded.(*api.SlackMemberChannelEvent)
+			if !ok {
+				return fmt.Errorf("unexpected member_joined_channel event type: %T", decoded)
+			}
+
+			if err := h.handleMemberJoinedChannelEvent(ctx, event); err != nil {
+				h.log.WithError(err).WithFields(
+					logrus.Fields{
+						"event":      event.EventID,
+						"user":       event.Event.User,
+						"channel_id": event.Event.Channel,
+					}).Error("Unable to process member joined channel event.")
+			}
+
+			return nil
+		},
+	)
+
+	h.eventRegistry.Register(
+		"user_change",
+		"",
+		api.DecodeSlackUserChangeEvent,
+		func(ctx context.Context, decoded interface{}) error {
+			event, ok := decoded.(*api.SlackUserChangeEvent)
+			if !ok {
+				return fmt.Errorf("unexpected user_change event type: %T", decoded)
+			}
+
+			if err := h.handleUserChangeEvent(ctx, event); err != nil {
+				h.log.WithError(err).WithFields(
+					logrus.Fields{
+						"event": event.EventID,
+						"user":  event.Event.User.Id,
+					}).Error("Unable to process user change event.")
+			}
+
+			return nil
+		},
+	)
+}
+
+// handleMemberLeftChannelEvent freezes the user's account in the channel and releases any bounties they own there.
+// Workspace wallets are still used in the user's other channels so they're only frozen when the user is deactivated.
+func (h *SlackBotHandler) handleMemberLeftChannelEvent(ctx context.Context, event *api.SlackMemberChannelEvent) error {
+	if h.config.AccountScope != service.AccountScopeWorkspace {
+		if err := h.channelAccountsRepo.SetFrozen(api.TeamIdFromContext(ctx), event.Event.User, event.Event.Channel, true); err != nil {
+			return errors.Wrapf(err, "failed to freeze channel account: %v, %v", event.Event.User, event.Event.Channel)
 		}
-	case "reaction_removed":
-		reactionRemovedEvent := &api.SlackReactionRemovedEvent{}
-		if err := json.Unmarshal(rawEvent, &reactionRemovedEvent); err != nil {
-			logrus.Errorf("Failed to ummarshall reaction_removed event: %v, %v", string(rawEvent), err.Error())
-			return
+	}
+
+	return h.releaseDepartedOwnerBounties(ctx, event.Event.User, event.Event.Channel)
+}
+
+// handleMemberJoinedChannelEvent unfreezes the account of a user that has returned to the channel.
+func (h *SlackBotHandler) handleMemberJoinedChannelEvent(ctx context.Context, event *api.SlackMemberChannelEvent) error {
+	if err := h.channelAccountsRepo.SetFrozen(api.TeamIdFromContext(ctx), event.Event.User, h.config.AccountChannelId(event.Event.Channel), false); err != nil {
+		return errors.Wrapf(err, "failed to unfreeze channel account: %v, %v", event.Event.User, event.Event.Channel)
+	}
+
+	return nil
+}
+
+// handleUserChangeEvent freezes all of a deactivated user's accounts and releases any bounties they own.
+func (h *SlackBotHandler) handleUserChangeEvent(ctx context.Context, event *api.SlackUserChangeEvent) error {
+	// Most user changes are profile updates. Reactivated users are unfrozen as they rejoin each channel.
+	if !event.Event.User.Deleted {
+		return nil
+	}
+
+	if err := h.channelAccountsRepo.SetFrozen(api.TeamIdFromContext(ctx), event.Event.User.Id, "", true); err != nil {
+		return errors.Wrapf(err, "failed to freeze channel accounts for deactivated user: %v", event.Event.User.Id)
+	}
+
+	return h.releaseDepartedOwnerBounties(ctx, event.Event.User.Id, "")
+}
+
+// releaseDepartedOwnerBounties hands over or refunds the open bounties owned by a user who is no longer around to
+// award them. If no channel is provided, bounties in every channel are released.
+func (h *SlackBotHandler) releaseDepartedOwnerBounties(ctx context.Context, userId string, channelId string) error {
+	// Every page is retrieved first as handing over or cancelling a bounty removes it from the list.
+	var messageBounties []*types.MessageBounty
+	for pageToken := ""; ; {
+		page, nextPageToken, err := h.messageBountiesRepo.List(
+			&types.ListMessageBountiesFilter{
+				TeamId:    api.TeamIdFromContext(ctx),
+				UserId:    userId,
+				ChannelId: channelId,
+				Status:    types.MessageBountyStatusOpen,
+			},
+			100,
+			pageToken,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list open bounties for departed user: %v", userId)
 		}
 
-		if err := h.handleReactionRemovedEvent(ctx, reactionRemovedEvent); err != nil {
-			h.log.WithError(err).WithFields(
-				logrus.Fields{
-					"event":    reactionRemovedEvent.EventID,
-					"reaction": reactionRemovedEvent.Event.Reaction,
-					"user":     reactionRemovedEvent.Event.User,
-				}).Error("Unable to process reaction removed event.")
+		messageBounties = append(messageBounties, page...)
+		if nextPageToken == "" {
+			break
+		}
 
-			return
+		pageToken = nextPageToken
+	}
+
+	for _, messageBounty := range messageBounties {
+		if h.config.DepartedOwnerBounties == service.DepartedOwnerBountiesHandover {
+			newOwner, err := h.messageBountiesService.HandOverBounty(ctx, messageBounty)
+			if err != nil {
+				h.log.WithError(err).WithField("message_id", messageBounty.MessageId).Error("Failed to hand over bounty from departed user.")
+				continue
+			}
+
+			if newOwner != "" {
+				h.apiClient.SendMessage(
+					ctx,
+					&api.SlackPostMessageRequest{
+						Text:     "<@" + userId + "> is no longer here so <@" + newOwner + "> can now award this bounty of " + fmt.Sprint(messageBounty.CurrentBounty) + ".",
+						Channel:  messageBounty.ChannelId,
+						ThreadTs: messageBounty.MessageId,
+					})
+				continue
+			}
 		}
-	default:
-		logrus.Errorf("Unknown event -> type: %v", event["event"].(map[string]interface{})["type"])
-		return
+
+		// Either refunds are configured or there was nobody to hand the bounty to.
+		_, refunded, err := h.messageBountiesService.CancelBounty(ctx, messageBounty)
+		if err != nil {
+			h.log.WithError(err).WithField("message_id", messageBounty.MessageId).Error("Failed to cancel bounty from departed user.")
+			continue
+		}
+
+		h.apiClient.SendMessage(
+			ctx,
+			&api.SlackPostMessageRequest{
+				Text:     "<@" + userId + "> is no longer here so the bounty has been cancelled and " + fmt.Sprint(refunded) + " has been refunded to its contributors.",
+				Channel:  messageBounty.ChannelId,
+				ThreadTs: messageBounty.MessageId,
+			})
+	}
+
+	return nil
+}
+
+// handleMessageDeletedEvent cancels the bounty on a deleted message and refunds everyone who boosted it.
+func (h *SlackBotHandler) handleMessageDeletedEvent(ctx context.Context, event *api.SlackMessageDeletedEvent) error {
+	messageBounties, _, err := h.messageBountiesRepo.List(
+		&types.ListMessageBountiesFilter{
+			MessageId: event.Event.DeletedTs,
+			TeamId:    api.TeamIdFromContext(ctx),
+			ChannelId: event.Event.Channel,
+		},
+		1,
+		"",
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the message bounty for a deleted message")
+	}
+
+	// Most deleted messages won't have a bounty.
+	if len(messageBounties) == 0 {
+		return nil
+	}
+
+	// Awarded bounties are left as they are, the reviewer has already been paid.
+	if messageBounties[0].Status != types.MessageBountyStatusOpen {
+		h.log.Infof("bounty on deleted message is no longer open: %v, %v", event.Event.DeletedTs, messageBounties[0].Status)
+		return nil
+	}
+
+	_, refunded, err := h.messageBountiesService.CancelBounty(ctx, messageBounties[0])
+	if err != nil {
+		return errors.Wrapf(err, "failed to cancel bounty on deleted message: %v", event.Event.DeletedTs)
 	}
+
+	// The message has gone so the notice is posted to the channel rather than the thread.
+	h.apiClient.SendMessage(
+		ctx,
+		&api.SlackPostMessageRequest{
+			Text:    "A message from <@" + messageBounties[0].UserId + "> with a bounty of " + fmt.Sprint(messageBounties[0].CurrentBounty) + " was deleted. The bounty has been cancelled and " + fmt.Sprint(refunded) + " has been refunded to its contributors.",
+			Channel: event.Event.Channel,
+		})
+
+	return nil
 }
 
 func (h *SlackBotHandler) handleReactionRemovedEvent(ctx context.Context, event *api.SlackReactionRemovedEvent) error {
@@ -118,6 +458,178 @@ func (h *SlackBotHandler) handleReactionRemovedEvent(ctx context.Context, event
 		return errors.Wrap(err, "failed to remove sent message if it exists.")
 	}
 
+	// Each channel can have its own reactions.
+	channelConfig := h.getChannelConfig(ctx, event.Event.Item.Channel)
+
+	// Check if it's a boost reaction.
+	for _, boostReaction := range channelConfig.BoostReactions {
+		if boostReaction.Emote == event.Event.Reaction {
+			return h.refundBoost(ctx, event)
+		}
+	}
+
+	// Check if it's a "task completed" reaction.
+	if strings.EqualFold(channelConfig.TaskCompletedByMeReaction, event.Event.Reaction) {
+		return h.retractClaim(ctx, event, channelConfig)
+	}
+
+	return nil
+}
+
+// retractClaim withdraws the user's claim on a bounty when they remove their "task completed" reaction. If others have
+// also claimed it the most recent of them becomes the claimant.
+func (h *SlackBotHandler) retractClaim(
+	ctx context.Context,
+	event *api.SlackReactionRemovedEvent,
+	channelConfig *service.ChannelConfig,
+) error {
+	messageBounties, _, err := h.messageBountiesRepo.List(
+		&types.ListMessageBountiesFilter{
+			MessageId: event.Event.Item.Ts,
+			TeamId:    api.TeamIdFromContext(ctx),
+			ChannelId: event.Event.Item.Channel,
+		},
+		1,
+		"",
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the message bounty")
+	}
+
+	if len(messageBounties) == 0 {
+		return nil
+	}
+
+	// Awarded bounties can't be changed.
+	if messageBounties[0].Status != types.MessageBountyStatusOpen {
+		h.log.Infof("claim not retracted as the bounty is no longer open: %v, %v", event.Event.Item.Ts, messageBounties[0].Status)
+		return nil
+	}
+
+	if err = h.bountyClaimsRepo.Upsert(&types.BountyClaim{
+		TeamId:    api.TeamIdFromContext(ctx),
+		MessageId: messageBounties[0].MessageId,
+		ChannelId: messageBounties[0].ChannelId,
+		UserId:    event.Event.User,
+		Status:    types.BountyClaimStatusRetracted,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to retract claim on bounty: %v", messageBounties[0].MessageId)
+	}
+
+	claimants, err := h.messageBountiesService.Claimants(ctx, messageBounties[0])
+	if err != nil {
+		return err
+	}
+
+	// Someone else has claimed the bounty since, their claim stands.
+	if messageBounties[0].AwardedTo != event.Event.User {
+		if len(claimants) > 0 {
+			h.apiClient.SendMessage(
+				ctx,
+				&api.SlackPostMessageRequest{
+					Text:     "<@" + event.Event.User + "> has retracted their claim, the bounty is now claimed by " + formatUserList(claimants) + ".",
+					Channel:  event.Event.Item.Channel,
+					ThreadTs: event.Event.Item.Ts,
+				})
+		}
+
+		return nil
+	}
+
+	var previousClaimer string
+	if len(claimants) > 0 {
+		previousClaimer = claimants[len(claimants)-1]
+	}
+
+	messageBounties[0].AwardedTo = previousClaimer
+	if _, err = h.messageBountiesRepo.Update(messageBounties[0]); err != nil {
+		return errors.Wrapf(err, "failed to retract claim on bounty: %v", messageBounties[0].MessageId)
+	}
+
+	text := "<@" + event.Event.User + "> has retracted their claim, the bounty can be claimed again with :" + channelConfig.TaskCompletedByMeReaction + ":."
+	if len(claimants) > 0 {
+		text = "<@" + event.Event.User + "> has retracted their claim, the bounty is now claimed by " + formatUserList(claimants) + "."
+	}
+
+	h.apiClient.SendMessage(
+		ctx,
+		&api.SlackPostMessageRequest{
+			Text:     text,
+			Channel:  event.Event.Item.Channel,
+			ThreadTs: event.Event.Item.Ts,
+		})
+
+	return nil
+}
+
+// formatUserList mentions each of the users, e.g. "<@a>, <@b> and <@c>".
+func formatUserList(userIds []string) string {
+	var mentions []string
+	for _, userId := range userIds {
+		mentions = append(mentions, "<@"+userId+">")
+	}
+
+	if len(mentions) < 2 {
+		return strings.Join(mentions, "")
+	}
+
+	return strings.Join(mentions[:len(mentions)-1], ", ") + " and " + mentions[len(mentions)-1]
+}
+
+// refundBoost returns the points to a user that has removed their boost reaction before the bounty was awarded.
+func (h *SlackBotHandler) refundBoost(ctx context.Context, event *api.SlackReactionRemovedEvent) error {
+	messageBounties, _, err := h.messageBountiesRepo.List(
+		&types.ListMessageBountiesFilter{
+			MessageId: event.Event.Item.Ts,
+			TeamId:    api.TeamIdFromContext(ctx),
+			ChannelId: event.Event.Item.Channel,
+		},
+		1,
+		"",
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the message bounty")
+	}
+
+	if len(messageBounties) == 0 {
+		return nil
+	}
+
+	// Awarded bounties can't be changed.
+	if messageBounties[0].Status != types.MessageBountyStatusOpen {
+		h.log.Infof("boost not refunded as the bounty is no longer open: %v, %v", event.Event.Item.Ts, messageBounties[0].Status)
+		return nil
+	}
+
+	refunded, err := h.messageBountiesService.RefundBoost(ctx, messageBounties[0], event.Event.User, event.Event.Reaction)
+	if errors.Cause(err) == service.ErrBountyBelowMinimum {
+		h.apiClient.SendMessage(
+			ctx,
+			&api.SlackPostMessageRequest{
+				Text: "<@" + event.Event.User + ">, your boost can't be removed as bounties in this channel need to be at least " +
+					fmt.Sprint(h.getChannelConfig(ctx, event.Event.Item.Channel).MinBounty) + ". The bounty can be cancelled instead.",
+				Channel:  event.Event.Item.Channel,
+				ThreadTs: event.Event.Item.Ts,
+			})
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "failed to refund boost: %v, %v", event.Event.Item.Ts, event.Event.User)
+	}
+
+	if refunded == 0 {
+		return nil
+	}
+
+	h.apiClient.SendMessage(
+		ctx,
+		&api.SlackPostMessageRequest{
+			Text:     "<@" + event.Event.User + "> has removed their boost of " + fmt.Sprint(refunded) + ", the bounty is now " + fmt.Sprint(messageBounties[0].CurrentBounty) + ".",
+			Channel:  event.Event.Item.Channel,
+			ThreadTs: event.Event.Item.Ts,
+		})
+
 	return nil
 }
 
@@ -128,22 +640,25 @@ func (h *SlackBotHandler) handleReactionAddedEvent(ctx context.Context, event *a
 		return nil
 	}
 
+	// Each channel can have its own reactions.
+	channelConfig := h.getChannelConfig(ctx, event.Event.Item.Channel)
+
 	// Check if it's a boost reaction.
-	for _, boostReaction := range h.config.BoostReactions {
+	for _, boostReaction := range channelConfig.BoostReactions {
 		if boostReaction.Emote == event.Event.Reaction {
 			return h.boostBounty(ctx, event, boostReaction.BoostValue)
 		}
 	}
 
 	// Check if it's a "task completed" reaction.
-	if strings.EqualFold(h.config.TaskCompletedByMeReaction, event.Event.Reaction) {
+	if strings.EqualFold(channelConfig.TaskCompletedByMeReaction, event.Event.Reaction) {
 		return h.claimBounty(ctx, event)
 	}
 
 	// Check if it's an "award bounty" reaction.
-	if strings.EqualFold(h.config.ReleaseBountyReaction, event.Event.Reaction) {
-		// NOTE: We don't assign a user when using the emote, it will attempt to use awarded_to.
-		return h.awardBounty(ctx, event.Event.Item.Ts, "", event.Event.User, event.Event.Reaction)
+	if strings.EqualFold(channelConfig.ReleaseBountyReaction, event.Event.Reaction) {
+		// NOTE: We don't assign users when using the emote, it will be split equally between the claimants.
+		return h.awardBounty(ctx, event.Event.Item.Channel, event.Event.Item.Ts, nil, nil, event.Event.User, event.Event.Reaction)
 	}
 
 	h.log.Infof("not a handled reaction event type: %v, %v", event.Event.Reaction, event.EventID)
@@ -156,6 +671,7 @@ func (h *SlackBotHandler) claimBounty(ctx context.Context, event *api.SlackReact
 	messageBounties, _, err := h.messageBountiesRepo.List(
 		&types.ListMessageBountiesFilter{
 			MessageId: event.Event.Item.Ts,
+			TeamId:    api.TeamIdFromContext(ctx),
 			ChannelId: event.Event.Item.Channel,
 		},
 		1,
@@ -171,22 +687,38 @@ func (h *SlackBotHandler) claimBounty(ctx context.Context, event *api.SlackReact
 	}
 
 	// Ensure that the bounty is still open.
-	if messageBounties[0].Status != 1 {
+	if messageBounties[0].Status != types.MessageBountyStatusOpen {
 		return fmt.Errorf("bounty can only be claimed while in an open state: %v, %v", event.Event.Item.Ts, event.Event.Item.Channel)
 	}
 
-	// Claim the bounty.
-	messageBounties[0].AwardedTo = event.Event.User
+	// Record the claim, anyone that claims the bounty is eligible for a share of it.
+	if err = h.bountyClaimsRepo.Upsert(&types.BountyClaim{
+		TeamId:    api.TeamIdFromContext(ctx),
+		MessageId: messageBounties[0].MessageId,
+		ChannelId: messageBounties[0].ChannelId,
+		UserId:    event.Event.User,
+		Status:    types.BountyClaimStatusActive,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to claim bounty: %v", messageBounties[0].MessageId)
+	}
 
-	// Mark the bounty as awarded.
+	// The most recent claimant is kept on the bounty for bounties claimed before claims were recorded.
+	messageBounties[0].AwardedTo = event.Event.User
 	if _, err = h.messageBountiesRepo.Update(messageBounties[0]); err != nil {
 		return errors.Wrapf(err, "failed to claim bounty: %v", messageBounties[0].MessageId)
 	}
 
+	text := "<@" + event.Event.User + "> has completed the task!"
+	if claimants, err := h.messageBountiesService.Claimants(ctx, messageBounties[0]); err != nil {
+		h.log.WithError(err).WithField("message_id", messageBounties[0].MessageId).Warn("Failed to list the claimants of a bounty.")
+	} else if len(claimants) > 1 {
+		text = "<@" + event.Event.User + "> has completed the task! The bounty will be split between " + formatUserList(claimants) + "."
+	}
+
 	h.apiClient.SendMessage(
 		ctx,
 		&api.SlackPostMessageRequest{
-			Text:     "<@" + event.Event.User + "> has completed the task!",
+			Text:     text,
 			Channel:  event.Event.Item.Channel,
 			ThreadTs: event.Event.Item.Ts,
 		})
@@ -194,11 +726,14 @@ func (h *SlackBotHandler) claimBounty(ctx context.Context, event *api.SlackReact
 	return nil
 }
 
-// awardBounty gives the current bounty on a message to the target user. If no target user is provided it will check if someone has attempted to claim it instead.
+// awardBounty gives the current bounty on a message to the target users, split according to their shares. If no shares
+// are provided it is split equally. If no target users are provided it is split equally between those that claimed it.
 func (h *SlackBotHandler) awardBounty(
 	ctx context.Context,
+	channelId string,
 	messageId string,
-	targetUserId string,
+	targetUserIds []string,
+	shares []int,
 	currentUserId string,
 	reaction string,
 ) error {
@@ -207,6 +742,8 @@ func (h *SlackBotHandler) awardBounty(
 	messageBounties, _, err := h.messageBountiesRepo.List(
 		&types.ListMessageBountiesFilter{
 			MessageId: messageId,
+			TeamId:    api.TeamIdFromContext(ctx),
+			ChannelId: channelId,
 		},
 		1,
 		"",
@@ -220,42 +757,51 @@ func (h *SlackBotHandler) awardBounty(
 		return fmt.Errorf("no bounty exists for message: %v", messageId)
 	}
 
-	// If we don't have a target user check if someone has already claimed it.
-	if targetUserId == "" {
-		if messageBounties[0].AwardedTo == "" {
+	// Check if it's already been awarded.
+	if messageBounties[0].Status == types.MessageBountyStatusAwarded {
+		// Check if the awarder is the same person who owns the message. If so we don't need to notify them (probably just adding the emote).
+		if messageBounties[0].UserId != currentUserId {
 			h.botMessagesService.SendRemovableBotMessage(
 				ctx,
 				&api.SlackPostMessageRequest{
-					Text:     "Heads up <@" + currentUserId + ">! Nobody has claimed the bounty yet :" + h.config.TaskCompletedByMeReaction + ":. Please wait until the bounty is claimed or use the message option to award it directly.",
+					Text:     "Heads up <@" + currentUserId + ">! This bounty has already been awarded to <@" + messageBounties[0].AwardedTo + ">.",
 					Channel:  messageBounties[0].ChannelId,
-					ThreadTs: messageBounties[0].MessageId,
+					ThreadTs: messageId,
 				},
 				currentUserId,
 				reaction,
 			)
-			return fmt.Errorf("nobody has claimed this bounty yet")
 		}
-	} else {
-		messageBounties[0].AwardedTo = targetUserId
+
+		return nil
 	}
 
-	// Check if it's already been awarded.
-	if messageBounties[0].Status == 2 {
-		// Check if the awarder is the same person who owns the message. If so we don't need to notify them (probably just adding the emote).
-		if messageBounties[0].UserId != currentUserId {
+	// Cancelled bounties have already been refunded.
+	if messageBounties[0].Status != types.MessageBountyStatusOpen {
+		return fmt.Errorf("bounty can only be awarded while in an open state: %v, %v", messageId, messageBounties[0].Status)
+	}
+
+	// If we don't have target users check if someone has already claimed it.
+	if len(targetUserIds) == 0 {
+		targetUserIds, err = h.messageBountiesService.DefaultRecipients(ctx, messageBounties[0])
+		if err != nil {
+			return err
+		}
+
+		if len(targetUserIds) == 0 {
+			channelConfig := h.getChannelConfig(ctx, messageBounties[0].ChannelId)
 			h.botMessagesService.SendRemovableBotMessage(
 				ctx,
 				&api.SlackPostMessageRequest{
-					Text:     "Heads up <@" + currentUserId + ">! This bounty has already been awarded to <@" + messageBounties[0].AwardedTo + ">.",
+					Text:     "Heads up <@" + currentUserId + ">! Nobody has claimed the bounty yet :" + channelConfig.TaskCompletedByMeReaction + ":. Please wait until the bounty is claimed or use the message option to award it directly.",
 					Channel:  messageBounties[0].ChannelId,
-					ThreadTs: messageId,
+					ThreadTs: messageBounties[0].MessageId,
 				},
 				currentUserId,
 				reaction,
 			)
+			return fmt.Errorf("nobody has claimed this bounty yet")
 		}
-
-		return nil
 	}
 
 	// Ensure that the owner of the bounty is the one who awards it.
@@ -274,42 +820,148 @@ func (h *SlackBotHandler) awardBounty(
 	}
 
 	// Ensure that they're not awarding it to themself.
-	if targetUserId == currentUserId {
-		h.botMessagesService.SendRemovableBotMessage(
+	for _, targetUserId := range targetUserIds {
+		if targetUserId == currentUserId {
+			h.botMessagesService.SendRemovableBotMessage(
+				ctx,
+				&api.SlackPostMessageRequest{
+					Text:     "Heads up <@" + currentUserId + ">! You cannot award the bounty to yourself.",
+					Channel:  messageBounties[0].ChannelId,
+					ThreadTs: messageId,
+				},
+				currentUserId,
+				reaction,
+			)
+			return nil
+		}
+	}
+
+	// The contributors are listed in the announcement so that people can see who funded the bounty.
+	contributors, err := h.messageBountiesService.Contributors(ctx, messageBounties[0])
+	if err != nil {
+		return err
+	}
+
+	// Without shares the bounty is split equally.
+	payouts, err := h.messageBountiesService.Payouts(ctx, messageBounties[0], targetUserIds, shares)
+	if err != nil {
+		return errors.Wrap(err, "failed to split the bounty between its recipients")
+	}
+
+	// Quick reviews are paid a bonus on top of their share, minted rather than taken from the bounty.
+	if err = h.messageBountiesService.ApplyFastReviewBonuses(ctx, messageBounties[0], payouts); err != nil {
+		return err
+	}
+
+	if err = h.messageBountiesService.AwardBounty(ctx, messageBounties[0], payouts); err != nil {
+		// Someone else awarded, cancelled or boosted the bounty while it was being split up.
+		if errors.Cause(err) == db.ErrBountyNotOpen {
+			h.apiClient.SendMessage(
+				ctx,
+				&api.SlackPostMessageRequest{
+					Text:     "<@" + currentUserId + "> the bounty changed while it was being awarded, please try again.",
+					Channel:  messageBounties[0].ChannelId,
+					ThreadTs: messageBounties[0].MessageId,
+				})
+		}
+
+		return err
+	}
+
+	// Post reply to message that the bounty has been awarded tagging the awarder.
+	text := "<@" + currentUserId + "> has awarded the bounty of " + fmt.Sprint(messageBounties[0].CurrentBounty) + " to <@" + payouts[0].UserId + ">."
+	if len(payouts) > 1 {
+		var recipients []string
+		for _, payout := range payouts {
+			recipients = append(recipients, "<@"+payout.UserId+"> ("+fmt.Sprint(payout.Amount)+")")
+		}
+
+		text = "<@" + currentUserId + "> has awarded the bounty of " + fmt.Sprint(messageBounties[0].CurrentBounty) + ", split between " + strings.Join(recipients, ", ") + "."
+	}
+
+	if len(contributors) > 0 {
+		text += " Funded by " + service.FormatContributors(contributors) + "."
+	}
+
+	var bonuses []string
+	for _, payout := range payouts {
+		if payout.Bonus > 0 {
+			bonuses = append(bonuses, "<@"+payout.UserId+"> (+"+fmt.Sprint(payout.Bonus)+")")
+		}
+	}
+
+	if len(bonuses) > 0 {
+		text += " Fast review bonus: " + strings.Join(bonuses, ", ") + "."
+	}
+
+	h.apiClient.SendMessage(
+		ctx,
+		&api.SlackPostMessageRequest{
+			Text:     text,
+			Channel:  messageBounties[0].ChannelId,
+			ThreadTs: messageId,
+		})
+
+	// The bounty has been awarded regardless, achievements are announced after it in the thread.
+	if err = h.achievementsService.EvaluateAward(ctx, messageBounties[0], payouts); err != nil {
+		h.log.WithError(err).WithField("message_id", messageId).Error("Failed to evaluate achievements for awarded bounty.")
+	}
+
+	return nil
+}
+
+// cancelBounty withdraws an open bounty at its owner's request and refunds each of its contributors. A notice is posted
+// to the bounty's thread listing who was refunded.
+func (h *SlackBotHandler) cancelBounty(ctx context.Context, channelId string, messageId string, currentUserId string) error {
+	messageBounties, _, err := h.messageBountiesRepo.List(
+		&types.ListMessageBountiesFilter{
+			MessageId: messageId,
+			TeamId:    api.TeamIdFromContext(ctx),
+			ChannelId: channelId,
+		},
+		1,
+		"",
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the message bounty")
+	}
+
+	if len(messageBounties) == 0 {
+		return fmt.Errorf("no bounty exists for message: %v", messageId)
+	}
+
+	// The modal may have been left open while the bounty was awarded or handed over.
+	if messageBounties[0].UserId != currentUserId {
+		return fmt.Errorf("bounty can only be cancelled by its owner: %v, %v", messageId, currentUserId)
+	}
+
+	// The bounty is only closed if it hasn't changed since it was read, the contributors refunded alongside it are
+	// the ones listed.
+	contributors, refunded, err := h.messageBountiesService.CancelBounty(ctx, messageBounties[0])
+	if errors.Cause(err) == db.ErrBountyNotOpen {
+		h.apiClient.SendMessage(
 			ctx,
 			&api.SlackPostMessageRequest{
-				Text:     "Heads up <@" + currentUserId + ">! You cannot award the bounty to yourself.",
+				Text:     "<@" + currentUserId + ">, the bounty changed while it was being cancelled, please try again.",
 				Channel:  messageBounties[0].ChannelId,
 				ThreadTs: messageId,
-			},
-			currentUserId,
-			reaction,
-		)
+			})
 		return nil
 	}
 
-	// Mark the bounty as awarded.
-	messageBounties[0].Status = 2
-	if _, err = h.messageBountiesRepo.Update(messageBounties[0]); err != nil {
-		return errors.Wrapf(err, "failed to award bounty: %v", messageBounties[0].MessageId)
-	}
-
-	// Retrieve the channel account for the person we're going to award the bounty.
-	channelAccount, err := h.getOrCreateChannelAccount(ctx, messageBounties[0].AwardedTo, messageBounties[0].ChannelId)
 	if err != nil {
-		return errors.Wrap(err, "failed to get or create channel account when awarded bounty")
+		return err
 	}
 
-	// Award the bounty.
-	if err = h.channelAccountsRepo.Award(channelAccount.Id, messageBounties[0].CurrentBounty); err != nil {
-		return errors.Wrapf(err, "failed to award bounty to user after retrieving their account: %v", messageBounties[0].MessageId)
+	text := "<@" + currentUserId + "> has cancelled the bounty of " + fmt.Sprint(messageBounties[0].CurrentBounty) + "."
+	if len(contributors) > 0 {
+		text = "<@" + currentUserId + "> has cancelled the bounty of " + fmt.Sprint(messageBounties[0].CurrentBounty) + " and " + fmt.Sprint(refunded) + " has been refunded to " + service.FormatContributors(contributors) + "."
 	}
 
-	// Post reply to message that the bounty has been awarded tagging the awarder.
 	h.apiClient.SendMessage(
 		ctx,
 		&api.SlackPostMessageRequest{
-			Text:     "<@" + currentUserId + "> has awarded the bounty of " + fmt.Sprint(messageBounties[0].CurrentBounty) + " to <@" + messageBounties[0].AwardedTo + ">.",
+			Text:     text,
 			Channel:  messageBounties[0].ChannelId,
 			ThreadTs: messageId,
 		})
@@ -319,25 +971,15 @@ func (h *SlackBotHandler) awardBounty(
 
 func (h *SlackBotHandler) boostBounty(ctx context.Context, event *api.SlackReactionAddedEvent, boostAmount int) error {
 	// To start, ensure that the user has an account they can use.
-	channelAccount, err := h.getOrCreateChannelAccount(ctx, event.Event.User, event.Event.Item.Channel)
+	channelAccount, err := h.channelAccountsService.GetOrCreate(ctx, event.Event.User, event.Event.Item.Channel)
 	if err != nil {
 		return err
 	}
 
-	// Ensure that the user has a balance that is able to give this reward.
+	// Ensure that the user has a balance that is able to give this reward. The spend itself is also conditional on the
+	// balance in case it changes before then.
 	if channelAccount.Balance < boostAmount {
-		h.botMessagesService.SendRemovableBotMessage(
-			ctx,
-			&api.SlackPostMessageRequest{
-				Text:     "Heads up <@" + event.Event.User + ">! Your balance isn't high enough to award :" + event.Event.Reaction + ":. Please remove your reaction to delete this message.",
-				Channel:  event.Event.Item.Channel,
-				ThreadTs: event.Event.Item.Ts,
-			},
-			event.Event.User,
-			event.Event.Reaction,
-		)
-
-		// TODO: Watch reaction removed events so that we can remove this reply when a user removes the reaction.
+		h.sendInsufficientFundsMessage(ctx, event)
 		return fmt.Errorf("account %v has a balance of %v which is not enough to add a bounty of %v", channelAccount.Id, channelAccount.Balance, boostAmount)
 	}
 
@@ -345,6 +987,7 @@ func (h *SlackBotHandler) boostBounty(ctx context.Context, event *api.SlackReact
 	messageBounties, _, err := h.messageBountiesRepo.List(
 		&types.ListMessageBountiesFilter{
 			MessageId: event.Event.Item.Ts,
+			TeamId:    api.TeamIdFromContext(ctx),
 			ChannelId: event.Event.Item.Channel,
 		},
 		1,
@@ -355,66 +998,143 @@ func (h *SlackBotHandler) boostBounty(ctx context.Context, event *api.SlackReact
 		return errors.Wrap(err, "failed to check for an existing message bounty")
 	}
 
-	var messageBounty *types.MessageBounty
-
-	// There is no existing bounty for this message.
-	if len(messageBounties) == 0 {
-		// Create a bounty for us to record awards etc.
-		messageBounty, err = h.messageBountiesRepo.Create(
-			&types.MessageBounty{
-				MessageId:     event.Event.Item.Ts, // This is the id of the message.
-				ChannelId:     event.Event.Item.Channel,
-				UserId:        event.Event.ItemUser, // This is the id of the user who created the message, not the emote.
-				CurrentBounty: 0,
-				Status:        1,
-				AwardedTo:     "",
-			},
-		)
-
-		if err != nil {
-			return errors.Wrap(err, "Failed to create an initial message bounty")
-		}
-	} else if len(messageBounties) > 1 {
+	if len(messageBounties) > 1 {
 		h.log.WithFields(logrus.Fields{
 			"message_id": event.Event.Item.Ts,
 			"channel_id": event.Event.Item.Channel,
 		}).Error("Multiple matching message bounties found.")
 		return errors.New("Multiple matching message bounties found, this should not occur.")
-	} else {
-		// Message bounty already exists, use it.
+	}
+
+	// Message bounty already exists, use it.
+	var messageBounty *types.MessageBounty
+	if len(messageBounties) == 1 {
 		messageBounty = messageBounties[0]
 	}
 
-	// Ensure that bounty hasn't already been awarded.
-	if messageBounty.AwardedTo != "" {
-		h.botMessagesService.SendRemovableBotMessage(
-			ctx,
-			&api.SlackPostMessageRequest{
-				Text:     "Heads up <@" + event.Event.User + ">! This bounty has already been awarded and can no longer be boosted. Please remove your emote to delete this message.",
-				Channel:  event.Event.Item.Channel,
-				ThreadTs: event.Event.Item.Ts,
-			},
-			event.Event.User,
-			event.Event.Reaction,
-		)
+	// Ensure that bounty hasn't already been awarded, cancelled or expired.
+	if messageBounty != nil && messageBounty.Status != types.MessageBountyStatusOpen {
+		h.sendBountyClosedMessage(ctx, event, messageBounty.Status)
 		return nil
 	}
 
-	// Decrement the user's balance.
-	if err = h.channelAccountsRepo.Spend(channelAccount.Id, boostAmount); err != nil {
-		return errors.Wrapf(err, "Failed to spend for account balance: %v, %v", channelAccount.Id, boostAmount)
+	// Boosts that break one of the channel's limits are rejected before a bounty is created for the message. They're
+	// checked again once the boost has been applied in case another boost was made in the meantime.
+	channelConfig := h.getChannelConfig(ctx, event.Event.Item.Channel)
+
+	var messageId string
+	var currentBounty int
+	if messageBounty != nil {
+		messageId, currentBounty = messageBounty.MessageId, messageBounty.CurrentBounty
+	}
+
+	limitMessage, err := h.messageBountiesService.BoostLimitMessage(ctx, nil, channelConfig, event.Event.Item.Channel, messageId, event.Event.User, currentBounty, boostAmount)
+	if err != nil {
+		return err
+	}
+
+	if limitMessage != "" {
+		h.sendBoostLimitMessage(ctx, event, limitMessage)
+		return nil
 	}
 
-	// Boost the message bounty.
-	if err = h.messageBountiesRepo.BoostBounty(messageBounty.MessageId, boostAmount); err != nil {
-		return errors.Wrapf(err, "Failed to boost bounty: %v, %v", messageBounty.MessageId, boostAmount)
+	// The bounty, spend, boost and contribution are applied together so that points can't be lost part way through and
+	// a bounty isn't left behind for a boost that failed.
+	if err = h.unitOfWork.Run(func(tx *sql.Tx) error {
+		// There is no existing bounty for this message, create one for us to record awards etc.
+		if messageBounty == nil {
+			created, err := h.messageBountiesRepo.WithTx(tx).Create(
+				&types.MessageBounty{
+					MessageId:     event.Event.Item.Ts, // This is the id of the message.
+					TeamId:        api.TeamIdFromContext(ctx),
+					ChannelId:     event.Event.Item.Channel,
+					UserId:        event.Event.ItemUser, // This is the id of the user who created the message, not the emote.
+					CurrentBounty: 0,
+					Status:        types.MessageBountyStatusOpen,
+					AwardedTo:     "",
+				},
+			)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create an initial message bounty")
+			}
+
+			messageBounty = created
+		}
+
+		// Decrement the user's balance.
+		if err := h.channelAccountsRepo.WithTx(tx).Spend(channelAccount.Id, boostAmount, messageBounty.MessageId); err != nil {
+			return errors.Wrapf(err, "Failed to spend for account balance: %v, %v", channelAccount.Id, boostAmount)
+		}
+
+		// Boost the message bounty.
+		if err := h.messageBountiesRepo.WithTx(tx).BoostBounty(messageBounty.TeamId, messageBounty.ChannelId, messageBounty.MessageId, boostAmount); err != nil {
+			return errors.Wrapf(err, "Failed to boost bounty: %v, %v", messageBounty.MessageId, boostAmount)
+		}
+
+		// The account and bounty are locked by the updates above so the limits are checked against their latest totals.
+		boosted, _, err := h.messageBountiesRepo.WithTx(tx).List(
+			&types.ListMessageBountiesFilter{
+				TeamId:    messageBounty.TeamId,
+				ChannelId: messageBounty.ChannelId,
+				MessageId: messageBounty.MessageId,
+			},
+			1,
+			"",
+		)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to retrieve boosted bounty: %v", messageBounty.MessageId)
+		}
+
+		if len(boosted) == 0 {
+			return errors.Errorf("Failed to retrieve boosted bounty: %v", messageBounty.MessageId)
+		}
+
+		currentBounty = boosted[0].CurrentBounty - boostAmount
+		if limitMessage, err = h.messageBountiesService.BoostLimitMessage(ctx, tx, channelConfig, messageBounty.ChannelId, messageBounty.MessageId, event.Event.User, currentBounty, boostAmount); err != nil {
+			return err
+		}
+
+		if limitMessage != "" {
+			return errBoostLimitExceeded
+		}
+
+		// Record who the points came from so that they can be refunded if the bounty is cancelled.
+		if err := h.contributionsRepo.WithTx(tx).Create(
+			&types.MessageBountyContribution{
+				TeamId:           api.TeamIdFromContext(ctx),
+				MessageId:        messageBounty.MessageId,
+				ChannelId:        messageBounty.ChannelId,
+				UserId:           event.Event.User,
+				ChannelAccountId: channelAccount.Id,
+				Reaction:         event.Event.Reaction,
+				Amount:           boostAmount,
+				Status:           types.MessageBountyContributionStatusActive,
+			},
+		); err != nil {
+			return errors.Wrapf(err, "Failed to record bounty contribution: %v, %v", messageBounty.MessageId, boostAmount)
+		}
+
+		return nil
+	}); err != nil {
+		switch errors.Cause(err) {
+		case db.ErrInsufficientFunds:
+			h.sendInsufficientFundsMessage(ctx, event)
+		case errBoostLimitExceeded:
+			h.sendBoostLimitMessage(ctx, event, limitMessage)
+			return nil
+		case db.ErrBountyNotOpen:
+			h.sendBountyClosedMessage(ctx, event, types.MessageBountyStatusCancelled)
+			return nil
+		}
+
+		return err
 	}
 
 	// Acknowledge the bounty in chat.
 	h.apiClient.SendMessage(
 		ctx,
 		&api.SlackPostMessageRequest{
-			Text:     "<@" + event.Event.User + "> has boosted the bounty to " + fmt.Sprint(messageBounty.CurrentBounty+boostAmount) + ".",
+			Text:     "<@" + event.Event.User + "> has boosted the bounty to " + fmt.Sprint(currentBounty+boostAmount) + ".",
 			Channel:  event.Event.Item.Channel,
 			ThreadTs: event.Event.Item.Ts,
 		})
@@ -422,74 +1142,54 @@ func (h *SlackBotHandler) boostBounty(ctx context.Context, event *api.SlackReact
 	return nil
 }
 
-// getOrCreateChannelAccount checks if the user has an account, if not create it.
-func (h *SlackBotHandler) getOrCreateChannelAccount(ctx context.Context, user string, channel string) (*types.ChannelAccount, error) {
-	// Retrieve the channel account if it exists.
-	channelAccounts, _, err := h.channelAccountsRepo.List(
-		&types.ListChannelAccountsFilter{
-			UserId:    user,
-			ChannelId: channel,
-		},
-		1,
-		"",
-		"",
-	)
-
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to check for an existing channel account")
-	}
-
-	// Channel account found, return to caller.
-	if len(channelAccounts) == 1 {
-		return channelAccounts[0], nil
+// sendBountyClosedMessage lets the user know that the bounty they tried to boost is closed, the message is removed
+// along with their reaction.
+func (h *SlackBotHandler) sendBountyClosedMessage(ctx context.Context, event *api.SlackReactionAddedEvent, status int) {
+	text := "This bounty is no longer open and can no longer be boosted."
+	if status == types.MessageBountyStatusAwarded {
+		text = "This bounty has already been awarded and can no longer be boosted."
 	}
 
-	if len(channelAccounts) > 1 {
-		return nil, fmt.Errorf("multiple channel accounts found for %v %v, this should not occur", user, channel)
-	}
-
-	// No channel account, we'll create a new one and return that.
-	channelAccount, err := h.channelAccountsRepo.Create(
-		&types.ChannelAccount{
-			UserId:         user,
-			ChannelId:      channel,
-			Balance:        h.config.DailyIncome,
-			EarnedToday:    0,
-			SpentToday:     0,
-			EarnedThisWeek: 0,
-			SpentThisWeek:  0,
-			EarnedThisYear: 0,
-			SpentThisYear:  0,
-			EarnedAllTime:  0,
-			SpentAllTime:   0,
+	h.botMessagesService.SendRemovableBotMessage(
+		ctx,
+		&api.SlackPostMessageRequest{
+			Text:     "Heads up <@" + event.Event.User + ">! " + text + " Please remove your emote to delete this message.",
+			Channel:  event.Event.Item.Channel,
+			ThreadTs: event.Event.Item.Ts,
 		},
+		event.Event.User,
+		event.Event.Reaction,
 	)
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to create a new channel account for %v, %v", channelAccount.UserId, channelAccount.ChannelId)
-	}
-
-	// Check if this is their first account with the bot (none exist for other channels)
-	channelAccounts, _, _ = h.channelAccountsRepo.List(
-		&types.ListChannelAccountsFilter{
-			UserId: user,
+// sendBoostLimitMessage lets the user know which of the channel's limits their boost would break, the message is removed
+// along with their reaction.
+func (h *SlackBotHandler) sendBoostLimitMessage(ctx context.Context, event *api.SlackReactionAddedEvent, limitMessage string) {
+	h.botMessagesService.SendRemovableBotMessage(
+		ctx,
+		&api.SlackPostMessageRequest{
+			Text:     "Heads up <@" + event.Event.User + ">! " + limitMessage + " Please remove your reaction to delete this message.",
+			Channel:  event.Event.Item.Channel,
+			ThreadTs: event.Event.Item.Ts,
 		},
-		3,
-		"",
-		"",
+		event.Event.User,
+		event.Event.Reaction,
 	)
+}
 
-	// We check if it's only the new row and send a welcome message to the user if it is.
-	if len(channelAccounts) == 1 {
-		h.apiClient.SendMessage(
-			ctx,
-			&api.SlackPostMessageRequest{
-				Text:    "Welcome to SlackBounties! You'll start off with 1 point and can earn more by completing bounties. Use */bountyemotes* to see the full list of emotes, */bountyme* to see your details and */bountydaily* to see the current leaderboard. Check out the following page for more info: " + h.config.DocumentationUrl,
-				Channel: user,
-			})
-	}
-
-	return channelAccount, nil
+// sendInsufficientFundsMessage lets the user know that they can't afford the boost, the message is removed along with
+// their reaction.
+func (h *SlackBotHandler) sendInsufficientFundsMessage(ctx context.Context, event *api.SlackReactionAddedEvent) {
+	h.botMessagesService.SendRemovableBotMessage(
+		ctx,
+		&api.SlackPostMessageRequest{
+			Text:     "Heads up <@" + event.Event.User + ">! Your balance isn't high enough to award :" + event.Event.Reaction + ":. Please remove your reaction to delete this message.",
+			Channel:  event.Event.Item.Channel,
+			ThreadTs: event.Event.Item.Ts,
+		},
+		event.Event.User,
+		event.Event.Reaction,
+	)
 }
 
 // getReactionTargetMessage retrieves the target message
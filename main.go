@@ -27,6 +27,8 @@ var (
 	wait                  = flag.Duration("graceful-timeout", time.Second*15, "the duration to wait before timing out")
 	slackWebhooksEndpoint = flag.String("slack-webhooks-endpoint", "0.0.0.0:3000", "Slack Webhooks Endpoint")
 	configFile            = flag.String("config", "./config/local.toml", "Path to the config file to use.")
+	socketMode            = flag.Bool("socket-mode", false, "Receive events over socket mode instead of the public http endpoints (overrides config).")
+	mergeWorkspaceWallets = flag.Bool("merge-workspace-wallets", false, "Merge each user's channel accounts into a workspace wallet and exit (requires AccountScope = \"workspace\").")
 )
 
 func main() {
@@ -45,6 +47,10 @@ func main() {
 
 	viper.Unmarshal(&config)
 
+	if err := config.Validate(); err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+
 	if err := run(log, config); err != nil {
 		log.Fatalf("failed running slack webhooks server: %s", err)
 	}
@@ -56,29 +62,80 @@ func run(
 ) error {
 	log.Println("Starting webhooks server: ", *slackWebhooksEndpoint)
 
+	if config.SigningSecret == "" && !*socketMode && !config.SocketModeEnabled {
+		log.Warn("No SigningSecret has been configured, all slack requests will be rejected.")
+	}
+
 	// Create a db connection.
 	sqlDb, err := initDb(config.DbConnection)
 	if err != nil {
 		log.Fatalf("failed to initialise database. %v", err)
 	}
 
+	// The transaction ledger is opt-in, balance changes are only recorded when it has been enabled.
+	var accountTransactionsRepo db.AccountTransactionsRepo
+	if config.TransactionLedgerEnabled {
+		accountTransactionsRepo = db.NewAccountTransactionsRepo(sqlDb, log)
+
+		seeded, err := accountTransactionsRepo.SeedOpeningBalances()
+		if err != nil {
+			log.Fatalf("failed to seed the transaction ledger. %v", err)
+		}
+
+		if seeded > 0 {
+			log.Infof("Recorded opening balances for %v channel accounts in the transaction ledger.", seeded)
+		}
+	}
+
 	// Instantiate repos.
-	channelAccountsRepo := db.NewChannelAccountsRepo(sqlDb, log)
+	channelAccountsRepo := db.NewChannelAccountsRepo(sqlDb, log, accountTransactionsRepo)
 	messageBountiesRepo := db.NewMessageBountiesRepo(sqlDb, log)
-	botStateRepo := db.NewBotStateRepo(sqlDb, log)
+	contributionsRepo := db.NewMessageBountyContributionsRepo(sqlDb, log)
+	bountyClaimsRepo := db.NewBountyClaimsRepo(sqlDb, log)
+	channelSettingsRepo := db.NewChannelSettingsRepo(sqlDb, log)
+	tipsRepo := db.NewTipsRepo(sqlDb, log)
+	achievementsRepo := db.NewAchievementsRepo(sqlDb, log)
+	seasonResultsRepo := db.NewSeasonResultsRepo(sqlDb, log)
+	botStateRepo := db.NewBotStateRepo(sqlDb, log, accountTransactionsRepo)
 	botMessagesRepo := db.NewBotMessagesRepo(sqlDb, log)
+	processedEventsRepo := db.NewProcessedEventsRepo(sqlDb, log)
+	installationsRepo := db.NewInstallationsRepo(sqlDb, log)
+	unitOfWork := db.NewUnitOfWork(sqlDb, log)
+
+	// Rows from before multiple workspaces were supported aren't visible until they've been given a team id.
+	if err = adoptLegacyRows(log, config, installationsRepo, unitOfWork); err != nil {
+		log.Fatalf("failed to assign legacy rows to a workspace. %v", err)
+	}
+
+	// Merging is a one off when switching to workspace wallets, the bot exits once it's done.
+	if *mergeWorkspaceWallets {
+		return mergeChannelAccounts(log, config, channelAccountsRepo, unitOfWork)
+	}
+
+	// Bot tokens are resolved per workspace so that the service is created before the api client.
+	installationsService := service.NewInstallationsService(config, installationsRepo, log)
 
 	// Instantiate api clients.
 	slackApiClient := api.NewSlackApiClient(
 		config.ApiConfig,
 		log,
+		installationsService,
 	)
 
 	// Instantiate services.
-	channelAccountsService := service.NewChannelAccountsService(config, channelAccountsRepo, *slackApiClient)
-	botStateService := service.NewBotStateService(config, botStateRepo, channelAccountsRepo, channelAccountsService, *slackApiClient, log)
+	channelSettingsService := service.NewChannelSettingsService(config, channelSettingsRepo, *slackApiClient, log)
+	channelAccountsService := service.NewChannelAccountsService(config, channelAccountsRepo, channelSettingsService, *slackApiClient)
+	messageBountiesService := service.NewMessageBountiesService(config, messageBountiesRepo, contributionsRepo, channelAccountsRepo, bountyClaimsRepo, channelAccountsService, channelSettingsService, botStateRepo, unitOfWork, log)
+	achievementsService := service.NewAchievementsService(config, achievementsRepo, bountyClaimsRepo, channelAccountsRepo, botStateRepo, *slackApiClient, log)
+	seasonsService := service.NewSeasonsService(config, botStateRepo, channelAccountsRepo, seasonResultsRepo)
+	botStateService := service.NewBotStateService(config, botStateRepo, channelAccountsRepo, messageBountiesRepo, processedEventsRepo, accountTransactionsRepo, seasonResultsRepo, channelAccountsService, messageBountiesService, achievementsService, seasonsService, *slackApiClient, unitOfWork, log)
+	tipsService := service.NewTipsService(config, tipsRepo, channelAccountsRepo, botStateRepo, unitOfWork, log)
 	botMessagesService := service.NewBotMessagesService(config, botMessagesRepo, *slackApiClient, log)
 
+	// Start the workers that process slack events once they've been acknowledged.
+	eventDispatcher := service.NewEventDispatcher(config.EventWorkers, config.EventQueueSize, log)
+	eventDispatcher.Start()
+
 	// Start the bot state maintainer to ensure we reset trackers when required etc.
 	go maintainBotState(botStateService, log)
 
@@ -89,18 +146,59 @@ func run(
 		slackApiClient,
 		channelAccountsRepo,
 		messageBountiesRepo,
+		contributionsRepo,
+		bountyClaimsRepo,
 		channelAccountsService,
+		messageBountiesService,
+		channelSettingsService,
+		tipsService,
+		achievementsService,
+		seasonsService,
 		botMessagesService,
 		botMessagesRepo,
+		processedEventsRepo,
+		eventDispatcher,
+		installationsService,
+		unitOfWork,
 	)
 
 	// Create router and add routes.
 	r := mux.NewRouter()
-	r.HandleFunc("/", handler.WebhookHandler).Methods("POST")
-	r.HandleFunc("/slash_commands", handler.SlashCommandHandler).Methods("POST")
-	r.HandleFunc("/interactions", handler.InteractionsHandler).Methods("POST")
 	r.HandleFunc("/ping", PingHandler)
 
+	// The install flow is only needed when serving multiple workspaces.
+	if config.ApiConfig.ClientId != "" {
+		r.HandleFunc("/install", handler.InstallHandler).Methods("GET")
+		r.HandleFunc("/oauth_redirect", handler.OAuthRedirectHandler).Methods("GET")
+	}
+
+	// In socket mode slack never calls us so the public endpoints aren't exposed.
+	socketModeDone := make(chan struct{})
+	socketModeCtx, cancelSocketMode := context.WithCancel(context.Background())
+	defer cancelSocketMode()
+
+	if *socketMode || config.SocketModeEnabled {
+		log.Println("Starting socket mode client.")
+
+		socketModeClient := api.NewSlackSocketModeClient(slackApiClient, log)
+		go func() {
+			defer close(socketModeDone)
+
+			if err := socketModeClient.Run(socketModeCtx, handler.SocketModeHandler); err != nil {
+				log.WithError(err).Error("Socket mode client stopped.")
+			}
+		}()
+	} else {
+		close(socketModeDone)
+
+		// Anything sent by slack must be signed before it reaches a handler.
+		slackRouter := r.Methods("POST").Subrouter()
+		slackRouter.Use(handlers.SlackSignatureMiddleware(config.SigningSecret, log))
+		slackRouter.HandleFunc("/", handler.WebhookHandler)
+		slackRouter.HandleFunc("/slash_commands", handler.SlashCommandHandler)
+		slackRouter.HandleFunc("/interactions", handler.InteractionsHandler)
+	}
+
 	srv := &http.Server{
 		Addr: *slackWebhooksEndpoint,
 		// Good practice to set timeouts to avoid Slowloris attacks.
@@ -133,9 +231,19 @@ func run(
 	// Doesn't block if no connections, but will otherwise wait
 	// until the timeout deadline.
 	srv.Shutdown(ctx)
-	// Optionally, you could run srv.Shutdown in a goroutine and block on
-	// <-ctx.Done() if your application should wait for other services
-	// to finalize based on context cancellation.
+
+	// Stop receiving from socket mode and wait for envelopes that are being handled.
+	cancelSocketMode()
+	select {
+	case <-socketModeDone:
+	case <-ctx.Done():
+	}
+
+	// Finish processing any events that have already been acknowledged so that bounties aren't lost.
+	if err := eventDispatcher.Drain(ctx); err != nil {
+		log.WithError(err).Error("Failed to drain queued events before shutting down.")
+	}
+
 	log.Println("shutting down")
 	os.Exit(0)
 
@@ -162,11 +270,9 @@ func initDb(connectionString string) (*sql.DB, error) {
 }
 
 func maintainBotState(botStateService *service.BotStateService, log *logrus.Logger) {
-	// Perform an initial tickover and then just check every few minutes.
-	err := botStateService.Tickover(context.Background(), time.Now())
-	if err != nil {
+	// Perform an initial tickover and then just check every few minutes. A failed tickover is retried on the next tick.
+	if err := botStateService.Tickover(context.Background(), time.Now()); err != nil {
 		log.WithError(err).Error("Failed to perform an initial tickover when starting maintain.")
-		return
 	}
 
 	// https://stackoverflow.com/a/40364927/522859
@@ -174,7 +280,9 @@ func maintainBotState(botStateService *service.BotStateService, log *logrus.Logg
 	for {
 		select {
 		case <-ticker.C:
-			botStateService.Tickover(context.Background(), time.Now())
+			if err := botStateService.Tickover(context.Background(), time.Now()); err != nil {
+				log.WithError(err).Error("Failed to perform tickover.")
+			}
 		}
 	}
 }
@@ -183,3 +291,64 @@ func PingHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "Pong")
 }
+
+// mergeChannelAccounts folds every channel account into its user's workspace wallet.
+func mergeChannelAccounts(
+	log *logrus.Logger,
+	config *service.Config,
+	channelAccountsRepo db.ChannelAccountsRepo,
+	unitOfWork *db.UnitOfWork,
+) error {
+	if config.AccountScope != service.AccountScopeWorkspace {
+		return fmt.Errorf("AccountScope must be set to %q before merging channel accounts", service.AccountScopeWorkspace)
+	}
+
+	var merged int64
+	if err := unitOfWork.Run(func(tx *sql.Tx) error {
+		var err error
+		merged, err = channelAccountsRepo.WithTx(tx).MergeIntoWorkspaceWallets()
+		return err
+	}); err != nil {
+		return err
+	}
+
+	log.Infof("Merged %v channel accounts into workspace wallets.", merged)
+	return nil
+}
+
+// adoptLegacyRows assigns the rows created before multiple workspaces were supported to the LegacyTeamId. Without it
+// those rows have an empty team id and can't be seen by any workspace, so a warning is logged if there are any.
+func adoptLegacyRows(
+	log *logrus.Logger,
+	config *service.Config,
+	installationsRepo db.InstallationsRepo,
+	unitOfWork *db.UnitOfWork,
+) error {
+	if config.LegacyTeamId == "" {
+		count, err := installationsRepo.LegacyRowCount()
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			log.Warnf("%v accounts and bounties don't belong to a workspace, set LegacyTeamId to the original workspace's team id to restore them.", count)
+		}
+
+		return nil
+	}
+
+	var adopted int64
+	if err := unitOfWork.Run(func(tx *sql.Tx) error {
+		var err error
+		adopted, err = installationsRepo.WithTx(tx).AdoptLegacyRows(config.LegacyTeamId)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if adopted > 0 {
+		log.Infof("Assigned %v legacy rows to team: %v", adopted, config.LegacyTeamId)
+	}
+
+	return nil
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/buzzology/slack_bot/db"
+	"github.com/buzzology/slack_bot/service/api"
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ReviewStreakDays is how many days in a row a user needs to be awarded a bounty to earn a review streak.
+const ReviewStreakDays = 5
+
+type IAchievementsService interface {
+	EvaluateAward(ctx context.Context, messageBounty *types.MessageBounty, payouts []*types.BountyPayout) error
+	EvaluateDailyChampion(ctx context.Context, channelId string) error
+	Earned(ctx context.Context, channelId string, userId string) ([]*types.AchievementCount, error)
+}
+
+// AchievementDefinition describes an achievement to the users that earn it.
+type AchievementDefinition struct {
+	Type        string
+	Name        string
+	Emote       string
+	Description string
+	// Repeatable achievements can be earned again, at most once a day.
+	Repeatable bool
+}
+
+// AchievementDefinitions are the achievements that can be earned, in the order they're displayed.
+var AchievementDefinitions = []*AchievementDefinition{
+	{
+		Type:        types.AchievementTypeFirstReviewOfTheDay,
+		Name:        "Early Bird",
+		Emote:       "sunrise",
+		Description: "awarded the first bounty of the day",
+		Repeatable:  true,
+	},
+	{
+		Type:        types.AchievementTypeReviewStreak,
+		Name:        "On a Roll",
+		Emote:       "fire",
+		Description: fmt.Sprintf("awarded a bounty %v days in a row", ReviewStreakDays),
+		Repeatable:  true,
+	},
+	{
+		Type:        types.AchievementTypeDailyChampion,
+		Name:        "Daily Champion",
+		Emote:       "trophy",
+		Description: "top of the daily leaderboard",
+		Repeatable:  true,
+	},
+	{
+		Type:        types.AchievementTypeTenBounties,
+		Name:        "Getting Started",
+		Emote:       "star",
+		Description: "awarded 10 bounties",
+	},
+	{
+		Type:        types.AchievementTypeHundredBounties,
+		Name:        "Centurion",
+		Emote:       "100",
+		Description: "awarded 100 bounties",
+	},
+}
+
+// AchievementsService awards achievements as bounties are awarded and during the tickover.
+type AchievementsService struct {
+	config              *Config
+	achievementsRepo    db.AchievementsRepo
+	bountyClaimsRepo    db.BountyClaimsRepo
+	channelAccountsRepo db.ChannelAccountsRepo
+	botStateRepo        db.BotStateRepo
+	apiClient           api.SlackApiClient
+	log                 *logrus.Logger
+}
+
+func NewAchievementsService(
+	config *Config,
+	achievementsRepo db.AchievementsRepo,
+	bountyClaimsRepo db.BountyClaimsRepo,
+	channelAccountsRepo db.ChannelAccountsRepo,
+	botStateRepo db.BotStateRepo,
+	apiClient api.SlackApiClient,
+	log *logrus.Logger,
+) *AchievementsService {
+	return &AchievementsService{
+		config:              config,
+		achievementsRepo:    achievementsRepo,
+		bountyClaimsRepo:    bountyClaimsRepo,
+		channelAccountsRepo: channelAccountsRepo,
+		botStateRepo:        botStateRepo,
+		apiClient:           apiClient,
+		log:                 log,
+	}
+}
+
+// EvaluateAward checks whether the recipients of a bounty have earned any achievements. New achievements are announced
+// in the bounty's thread. It should be called once the bounty has been awarded.
+func (s *AchievementsService) EvaluateAward(ctx context.Context, messageBounty *types.MessageBounty, payouts []*types.BountyPayout) error {
+	teamId := api.TeamIdFromContext(ctx)
+
+	dayStart, err := s.dayStart()
+	if err != nil {
+		return err
+	}
+
+	// With workspace wallets achievements are earned for the whole workspace, so awards in every channel count.
+	claimsChannelId := messageBounty.ChannelId
+	if s.config.AccountScope == AccountScopeWorkspace {
+		claimsChannelId = ""
+	}
+
+	// Only this bounty has been awarded in the channel today. Bounties are counted rather than claims so that a split
+	// bounty still counts as the first.
+	awardedToday, err := s.bountyClaimsRepo.AwardedCount(teamId, claimsChannelId, "", dayStart)
+	if err != nil {
+		return err
+	}
+
+	for _, payout := range payouts {
+		var achievementTypes []string
+		if awardedToday == 1 {
+			achievementTypes = append(achievementTypes, types.AchievementTypeFirstReviewOfTheDay)
+		}
+
+		// Streaks longer than a year are still rewarded, just based on the last year.
+		days, err := s.bountyClaimsRepo.AwardedDays(teamId, claimsChannelId, payout.UserId, time.Now().AddDate(-1, 0, 0))
+		if err != nil {
+			return err
+		}
+
+		if streak := consecutiveDays(days); streak > 0 && streak%ReviewStreakDays == 0 {
+			achievementTypes = append(achievementTypes, types.AchievementTypeReviewStreak)
+		}
+
+		awarded, err := s.bountyClaimsRepo.AwardedCount(teamId, claimsChannelId, payout.UserId, time.Time{})
+		if err != nil {
+			return err
+		}
+
+		if awarded >= 10 {
+			achievementTypes = append(achievementTypes, types.AchievementTypeTenBounties)
+		}
+
+		if awarded >= 100 {
+			achievementTypes = append(achievementTypes, types.AchievementTypeHundredBounties)
+		}
+
+		earned, err := s.earn(ctx, messageBounty.ChannelId, payout.UserId, messageBounty.MessageId, achievementTypes, dayStart)
+		if err != nil {
+			return err
+		}
+
+		for _, definition := range earned {
+			s.announce(ctx, messageBounty.ChannelId, messageBounty.MessageId, payout.UserId, definition)
+		}
+	}
+
+	return nil
+}
+
+// EvaluateDailyChampion awards the user at the top of the channel's daily leaderboard. It's called during the tickover
+// before the daily earnings are reset.
+func (s *AchievementsService) EvaluateDailyChampion(ctx context.Context, channelId string) error {
+	leaders, err := s.channelAccountsRepo.LeadersToday(api.TeamIdFromContext(ctx), s.config.AccountChannelId(channelId), 100, 1)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve the daily leader for channel: %v", channelId)
+	}
+
+	if len(leaders) == 0 || leaders[0].EarnedToday <= 0 {
+		return nil
+	}
+
+	dayStart, err := s.dayStart()
+	if err != nil {
+		return err
+	}
+
+	earned, err := s.earn(ctx, channelId, leaders[0].UserId, "", []string{types.AchievementTypeDailyChampion}, dayStart)
+	if err != nil {
+		return err
+	}
+
+	for _, definition := range earned {
+		s.announce(ctx, channelId, "", leaders[0].UserId, definition)
+	}
+
+	return nil
+}
+
+// Earned returns how many times the user has earned each achievement in the channel, or the workspace when the account
+// scope is "workspace".
+func (s *AchievementsService) Earned(ctx context.Context, channelId string, userId string) ([]*types.AchievementCount, error) {
+	counts, err := s.achievementsRepo.Counts(api.TeamIdFromContext(ctx), s.config.AccountChannelId(channelId), userId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve achievements: %v, %v", userId, channelId)
+	}
+
+	return counts, nil
+}
+
+// earn records each of the achievements that the user hasn't already earned (today, for repeatable achievements) and
+// returns their definitions.
+func (s *AchievementsService) earn(
+	ctx context.Context,
+	channelId string,
+	userId string,
+	messageId string,
+	achievementTypes []string,
+	dayStart time.Time,
+) ([]*AchievementDefinition, error) {
+	if len(achievementTypes) == 0 {
+		return nil, nil
+	}
+
+	counts, err := s.Earned(ctx, channelId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]*types.AchievementCount{}
+	for _, count := range counts {
+		existing[count.Type] = count
+	}
+
+	var earned []*AchievementDefinition
+	for _, achievementType := range achievementTypes {
+		definition := GetAchievementDefinition(achievementType)
+		if definition == nil {
+			return nil, errors.Errorf("unknown achievement: %v", achievementType)
+		}
+
+		if count, ok := existing[achievementType]; ok && (!definition.Repeatable || !count.LastEarned.Before(dayStart)) {
+			continue
+		}
+
+		if err = s.achievementsRepo.Create(
+			&types.Achievement{
+				TeamId:    api.TeamIdFromContext(ctx),
+				ChannelId: s.config.AccountChannelId(channelId),
+				UserId:    userId,
+				Type:      achievementType,
+				MessageId: messageId,
+			},
+		); err != nil {
+			return nil, err
+		}
+
+		earned = append(earned, definition)
+	}
+
+	return earned, nil
+}
+
+// announce lets the channel know that the user has earned an achievement, in the bounty's thread if there is one.
+func (s *AchievementsService) announce(ctx context.Context, channelId string, messageId string, userId string, definition *AchievementDefinition) {
+	if _, err := s.apiClient.SendMessage(ctx, &api.SlackPostMessageRequest{
+		Text:     fmt.Sprintf("<@%v> has earned the :%v: *%v* achievement (%v)!", userId, definition.Emote, definition.Name, definition.Description),
+		Channel:  channelId,
+		ThreadTs: messageId,
+	}); err != nil {
+		s.log.WithError(err).WithFields(logrus.Fields{
+			"user_id":     userId,
+			"channel_id":  channelId,
+			"achievement": definition.Type,
+		}).Error("Failed to announce achievement.")
+	}
+}
+
+// dayStart returns when the current bot day started, repeatable achievements can only be earned once a day.
+func (s *AchievementsService) dayStart() (time.Time, error) {
+	botState, err := s.botStateRepo.Get()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to retrieve bot state to evaluate achievements")
+	}
+
+	if botState == nil {
+		return time.Now().Add(-time.Hour * 24), nil
+	}
+
+	return botState.DayTickover.AsTime().Add(-time.Hour * 24), nil
+}
+
+// GetAchievementDefinition returns the definition of the achievement, nil if it isn't known.
+func GetAchievementDefinition(achievementType string) *AchievementDefinition {
+	for _, definition := range AchievementDefinitions {
+		if definition.Type == achievementType {
+			return definition
+		}
+	}
+
+	return nil
+}
+
+// FormatAchievements lists the achievements in the order they're defined, e.g. ":fire: On a Roll x2".
+func FormatAchievements(counts []*types.AchievementCount) string {
+	earned := map[string]int{}
+	for _, count := range counts {
+		earned[count.Type] = count.Count
+	}
+
+	var formatted []string
+	for _, definition := range AchievementDefinitions {
+		count, ok := earned[definition.Type]
+		if !ok {
+			continue
+		}
+
+		text := fmt.Sprintf(":%v: %v", definition.Emote, definition.Name)
+		if definition.Repeatable && count > 1 {
+			text += fmt.Sprintf(" x%v", count)
+		}
+
+		formatted = append(formatted, text)
+	}
+
+	return strings.Join(formatted, "\n")
+}
+
+// consecutiveDays counts the run of days at the start of the list, which is expected to be most recent first.
+func consecutiveDays(days []time.Time) int {
+	if len(days) == 0 {
+		return 0
+	}
+
+	streak := 1
+	for i := 1; i < len(days); i++ {
+		if !days[i].AddDate(0, 0, 1).Equal(days[i-1]) {
+			break
+		}
+
+		streak++
+	}
+
+	return streak
+}
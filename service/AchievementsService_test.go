@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConsecutiveDays(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2026, time.March, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		days []time.Time
+		want int
+	}{
+		{
+			name: "no days",
+			days: nil,
+			want: 0,
+		},
+		{
+			name: "single day",
+			days: []time.Time{day(10)},
+			want: 1,
+		},
+		{
+			name: "unbroken run",
+			days: []time.Time{day(10), day(9), day(8)},
+			want: 3,
+		},
+		{
+			name: "only the most recent run counts",
+			days: []time.Time{day(10), day(9), day(7), day(6), day(5)},
+			want: 2,
+		},
+		{
+			name: "gap straight away",
+			days: []time.Time{day(10), day(8), day(7)},
+			want: 1,
+		},
+		{
+			name: "run across the end of the month",
+			days: []time.Time{time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC), day(31), day(30)},
+			want: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := consecutiveDays(tt.days); got != tt.want {
+				t.Errorf("consecutiveDays() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -54,6 +54,7 @@ func (s *BotMessagesService) RemoveSentBotMessageIfExists(
 	// Check if there's a bot message that we need to remove.
 	botMessages, _, err := s.botMessagesRepo.List(
 		&types.ListBotMessagesFilter{
+			TeamId:    api.TeamIdFromContext(ctx),
 			MessageId: targetMessageId,
 			UserId:    targetUserId,
 			ChannelId: targetChannelId,
@@ -125,6 +126,7 @@ func (s *BotMessagesService) SendRemovableBotMessage(
 	if targetReaction != "" {
 		if err = s.botMessagesRepo.Create(
 			&types.BotMessage{
+				TeamId:        api.TeamIdFromContext(ctx),
 				MessageId:     res.Message.Ts,
 				SentMessageId: postMessageRequest.ThreadTs,
 				ChannelId:     postMessageRequest.Channel,
@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/buzzology/slack_bot/db"
 	"github.com/buzzology/slack_bot/service/api"
+	"github.com/buzzology/slack_bot/types"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -19,8 +23,16 @@ type BotStateService struct {
 	config                 *Config
 	botStateRepo           db.BotStateRepo
 	channelAccountsRepo    db.ChannelAccountsRepo
+	messageBountiesRepo    db.MessageBountiesRepo
+	processedEventsRepo    db.ProcessedEventsRepo
+	transactionsRepo       db.AccountTransactionsRepo
+	seasonResultsRepo      db.SeasonResultsRepo
 	apiClient              api.SlackApiClient
 	channelAccountsService *ChannelAccountsService
+	messageBountiesService *MessageBountiesService
+	achievementsService    *AchievementsService
+	seasonsService         *SeasonsService
+	unitOfWork             *db.UnitOfWork
 	log                    *logrus.Logger
 }
 
@@ -28,124 +40,394 @@ func NewBotStateService(
 	config *Config,
 	botStateRepo db.BotStateRepo,
 	channelAccountsRepo db.ChannelAccountsRepo,
+	messageBountiesRepo db.MessageBountiesRepo,
+	processedEventsRepo db.ProcessedEventsRepo,
+	transactionsRepo db.AccountTransactionsRepo,
+	seasonResultsRepo db.SeasonResultsRepo,
 	channelAccountsService *ChannelAccountsService,
+	messageBountiesService *MessageBountiesService,
+	achievementsService *AchievementsService,
+	seasonsService *SeasonsService,
 	apiClient api.SlackApiClient,
+	unitOfWork *db.UnitOfWork,
 	log *logrus.Logger,
 ) *BotStateService {
 	return &BotStateService{
 		config:                 config,
 		botStateRepo:           botStateRepo,
 		channelAccountsRepo:    channelAccountsRepo,
+		messageBountiesRepo:    messageBountiesRepo,
+		processedEventsRepo:    processedEventsRepo,
+		transactionsRepo:       transactionsRepo,
+		seasonResultsRepo:      seasonResultsRepo,
 		channelAccountsService: channelAccountsService,
+		messageBountiesService: messageBountiesService,
+		achievementsService:    achievementsService,
+		seasonsService:         seasonsService,
 		apiClient:              apiClient,
+		unitOfWork:             unitOfWork,
 		log:                    log,
 	}
 }
 
 // Tickover checks for and then actions any tasks associated with daily, weekly, ..., tickovers.
 func (s *BotStateService) Tickover(ctx context.Context, now time.Time) error {
-	// TODO: Wrap all of this in a transaction.
 	botState, err := s.botStateRepo.Get()
 	if err != nil {
 		return errors.Wrap(err, "Failed to retrieve bot state while performing tickover.")
 	}
 
-	// Retrieve all channel accounts.
-	channelIds, err := s.channelAccountsRepo.DistinctChannels()
+	// Retrieve all channel accounts. Workspace wallets aren't tied to a channel so the leaderboards are sent to every
+	// channel that has had a bounty instead.
+	var channels []*types.TeamChannel
+	if s.config.AccountScope == AccountScopeWorkspace {
+		channels, err = s.messageBountiesRepo.DistinctChannels()
+	} else {
+		channels, err = s.channelAccountsRepo.DistinctChannels()
+	}
 	if err != nil {
 		return errors.Wrap(err, "Failed to retrieve channel accounts for tickover.")
 	}
 
-	// Reset daily if required.
-	if botState.DayTickover.AsTime().Before(now) {
-		// Send a leaderboard to each channel.
-		for _, channelId := range channelIds {
-			leaderboard, err := s.channelAccountsService.DailyLeaderboard(ctx, channelId)
-			if err != nil {
-				s.log.Errorf("failed to show the daily leaderboard for: %v", channelId, err)
-				continue
+	dayTickover := botState.DayTickover.AsTime().Before(now)
+	weekTickover := botState.WeekTickover.AsTime().Before(now)
+	yearTickover := botState.YearTickover.AsTime().Before(now)
+
+	// The season changes when the configured season that includes now isn't the one being tracked. A misconfigured
+	// season shouldn't hold up the rest of the tickover so it's only logged.
+	season, err := s.seasonsService.ActiveSeason(now)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to determine the active season.")
+	}
+
+	var seasonName string
+	if season != nil {
+		seasonName = season.Name
+	}
+
+	seasonTickover := err == nil && seasonName != botState.Season
+
+	// Leaderboards are sent before anything is reset. If the tickover fails they'll be sent again on the next attempt.
+	if dayTickover {
+		s.sendLeaderboards(ctx, channels, "daily", s.channelAccountsService.DailyLeaderboard)
+
+		// The top of each daily leaderboard is made the daily champion.
+		for _, channel := range channels {
+			if err := s.achievementsService.EvaluateDailyChampion(api.ContextWithTeamId(ctx, channel.TeamId), channel.ChannelId); err != nil {
+				s.log.WithError(err).WithField("channel_id", channel.ChannelId).Error("Failed to evaluate the daily champion.")
 			}
+		}
+	}
 
-			if _, err := s.apiClient.SendMessage(ctx, &api.SlackPostMessageRequest{
-				Blocks:  leaderboard.Blocks,
-				Channel: channelId,
-			}); err != nil {
-				s.log.Errorf("failed to send the daily leaderboard for: %v", channelId, err)
-				continue
+	if weekTickover {
+		s.sendLeaderboards(ctx, channels, "weekly", s.channelAccountsService.WeeklyLeaderboard)
+	}
+
+	if yearTickover {
+		s.sendLeaderboards(ctx, channels, "yearly", s.channelAccountsService.YearlyLeaderboard)
+	}
+
+	// Post the final standings of the season that has ended.
+	if seasonTickover && botState.Season != "" {
+		s.sendLeaderboards(ctx, channels, "season", func(ctx context.Context, channelId string) (*api.SlackBlocks, error) {
+			return s.seasonsService.Standings(ctx, channelId, botState.Season)
+		})
+	}
+
+	// Warn the owners of bounties that are about to expire and then close any that have. A failure here mustn't hold up
+	// the resets and income, the bounties are picked up again on the next tickover.
+	if err = s.expireBounties(ctx, now); err != nil {
+		s.log.WithError(err).Error("Failed to expire bounties.")
+	}
+
+	// Expire processed events once slack will no longer retry them.
+	if _, err = s.processedEventsRepo.DeleteOlderThan(
+		now.Add(-time.Hour * time.Duration(s.config.ProcessedEventsRetentionHours)),
+	); err != nil {
+		return errors.Wrap(err, "failed to expire processed events")
+	}
+
+	// The resets, income and decay are applied with the new bot state so that a failure can't apply them twice.
+	return s.unitOfWork.Run(func(tx *sql.Tx) error {
+		channelAccountsRepo := s.channelAccountsRepo.WithTx(tx)
+		botStateRepo := s.botStateRepo.WithTx(tx)
+
+		// Reset daily if required.
+		if dayTickover {
+			if err := channelAccountsRepo.ResetDaily(); err != nil {
+				return errors.Wrap(err, "Failed to reset daily channel accounts.")
+			}
+
+			// Set when the next tickover will occur.
+			botState.DayTickover = *timestamppb.New(botState.DayTickover.AsTime().Add(time.Hour * 24))
+
+			// We apply decay and income daily as well.
+			if err := botStateRepo.ApplyIncomeAndDecay(s.decayPolicy(), s.config.DailyIncome); err != nil {
+				return err
+			}
+
+			if s.transactionsRepo != nil {
+				if err := s.maintainTransactionLedger(s.transactionsRepo.WithTx(tx), now); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Reset weekly if required.
+		if weekTickover {
+			if err := channelAccountsRepo.ResetWeekly(); err != nil {
+				return errors.Wrap(err, "failed to reset weekly")
+			}
+
+			// Set when the next tickover should occur.
+			botState.WeekTickover = *timestamppb.New(botState.DayTickover.AsTime().Add(time.Hour * 730))
+		}
+
+		// Reset yearly if required.
+		if yearTickover {
+			if err := channelAccountsRepo.ResetYearly(); err != nil {
+				return errors.Wrap(err, "failed to reset yearly")
+			}
+
+			// Set when the next tickover should occur.
+			botState.YearTickover = *timestamppb.New(botState.YearTickover.AsTime().Add(time.Hour * 8760))
+		}
+
+		// Archive the season that has ended and start tracking the next one.
+		if seasonTickover {
+			if err := s.changeSeason(channelAccountsRepo, s.seasonResultsRepo.WithTx(tx), botState, season); err != nil {
+				return err
 			}
 		}
 
-		if err = s.channelAccountsRepo.ResetDaily(); err != nil {
-			return errors.Wrap(err, "Failed to reset daily channel accounts.")
+		// Update the bot's state before returning.
+		if _, err := botStateRepo.Update(botState); err != nil {
+			return errors.Wrap(err, "failed to update bot_state when performing tickover.")
 		}
 
-		// Set when the next tickover will occur.
-		botState.DayTickover = *timestamppb.New(botState.DayTickover.AsTime().Add(time.Hour * 24))
+		return nil
+	})
+}
 
-		// We apply decay and income daily as well.
-		if err = s.botStateRepo.ApplyIncomeAndDecay(s.config.DailyDecay, s.config.DailyIncome); err != nil {
+// changeSeason archives the standings of the season being tracked, if there is one, and resets the season earnings.
+// Balances are soft reset when a new season starts if a percentage has been configured.
+func (s *BotStateService) changeSeason(
+	channelAccountsRepo db.ChannelAccountsRepo,
+	seasonResultsRepo db.SeasonResultsRepo,
+	botState *types.BotState,
+	next *Season,
+) error {
+	if botState.Season != "" {
+		if _, err := seasonResultsRepo.Archive(botState.Season); err != nil {
 			return err
 		}
 	}
 
-	// Reset weekly if required.
-	if botState.WeekTickover.AsTime().Before(now) {
-		// Send a leaderboard to each channel.
-		for _, channelId := range channelIds {
-			leaderboard, err := s.channelAccountsService.WeeklyLeaderboard(ctx, channelId)
-			if err != nil {
-				s.log.Errorf("failed to display weekly leaderboard for: %v", channelId, err)
-				continue
-			}
+	if err := channelAccountsRepo.ResetSeason(); err != nil {
+		return errors.Wrap(err, "failed to reset season")
+	}
 
-			if _, err := s.apiClient.SendMessage(ctx, &api.SlackPostMessageRequest{
-				Blocks:  leaderboard.Blocks,
-				Channel: channelId,
-			}); err != nil {
-				s.log.Errorf("failed to send the weekly leaderboard for: %v", channelId, err)
-				continue
-			}
+	botState.Season = ""
+	if next == nil {
+		return nil
+	}
+
+	if err := channelAccountsRepo.ResetSeasonBalances(s.config.SeasonBalanceResetPercentage); err != nil {
+		return errors.Wrapf(err, "failed to reset balances for season: %v", next.Name)
+	}
+
+	botState.Season = next.Name
+	return nil
+}
+
+// decayPolicy returns the decay and balance cap from the config.
+func (s *BotStateService) decayPolicy() *types.DecayPolicy {
+	return &types.DecayPolicy{
+		Mode:       s.config.DecayMode,
+		Amount:     s.config.DailyDecay,
+		Percentage: s.config.BalanceDecayPercentage,
+		Tiers:      s.config.DecayTiers,
+		MaxBalance: s.config.MaxBalance,
+	}
+}
+
+// sendLeaderboards posts a leaderboard to each of the channels.
+func (s *BotStateService) sendLeaderboards(
+	ctx context.Context,
+	channels []*types.TeamChannel,
+	period string,
+	leaderboard func(ctx context.Context, channelId string) (*api.SlackBlocks, error),
+) {
+	for _, channel := range channels {
+		// Each channel's leaderboard needs to be sent with its workspace's token.
+		channelCtx := api.ContextWithTeamId(ctx, channel.TeamId)
+
+		blocks, err := leaderboard(channelCtx, channel.ChannelId)
+		if err != nil {
+			s.log.Errorf("failed to display %v leaderboard for: %v, %v", period, channel.ChannelId, err)
+			continue
 		}
 
-		if err = s.channelAccountsRepo.ResetWeekly(); err != nil {
-			return errors.Wrap(err, "failed to reset weekly")
+		if _, err := s.apiClient.SendMessage(channelCtx, &api.SlackPostMessageRequest{
+			Blocks:  blocks.Blocks,
+			Channel: channel.ChannelId,
+		}); err != nil {
+			s.log.Errorf("failed to send the %v leaderboard for: %v, %v", period, channel.ChannelId, err)
+			continue
 		}
+	}
+}
 
-		// Set when the next tickover should occur.
-		botState.WeekTickover = *timestamppb.New(botState.DayTickover.AsTime().Add(time.Hour * 730))
+// expireBounties warns the owner of each bounty that is about to expire and then closes any bounties that have been
+// open for longer than the expiry, oldest first. Bounties that fail are logged and skipped so that they don't hold up
+// the rest, they're retried on the next tickover.
+func (s *BotStateService) expireBounties(ctx context.Context, now time.Time) error {
+	if s.config.BountyExpiryDays <= 0 {
+		return nil
 	}
 
-	// Reset yearly if required.
-	if botState.YearTickover.AsTime().Before(now) {
-		// Send a leaderboard to each channel.
-		for _, channelId := range channelIds {
-			leaderboard, err := s.channelAccountsService.YearlyLeaderboard(ctx, channelId)
-			if err != nil {
-				s.log.Errorf("failed to display yearly leaderboard for: %v", channelId, err)
-				continue
-			}
+	expiresBefore := now.AddDate(0, 0, -s.config.BountyExpiryDays)
 
-			if _, err := s.apiClient.SendMessage(ctx, &api.SlackPostMessageRequest{
-				Blocks:  leaderboard.Blocks,
-				Channel: channelId,
-			}); err != nil {
-				s.log.Errorf("failed to send the yearly leaderboard for: %v", channelId, err)
+	if s.config.BountyExpiryWarningDays > 0 {
+		messageBounties, err := s.listAllBounties(
+			&types.ListMessageBountiesFilter{
+				Status:              types.MessageBountyStatusOpen,
+				CreatedBefore:       expiresBefore.AddDate(0, 0, s.config.BountyExpiryWarningDays),
+				ExcludeExpiryWarned: true,
+			},
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve bounties that are about to expire")
+		}
+
+		for _, messageBounty := range messageBounties {
+			// Bounties that have already expired are closed below without a warning.
+			if messageBounty.Created.AsTime().Before(expiresBefore) {
 				continue
 			}
+
+			s.warnBountyExpiry(api.ContextWithTeamId(ctx, messageBounty.TeamId), messageBounty)
+		}
+	}
+
+	messageBounties, err := s.listAllBounties(
+		&types.ListMessageBountiesFilter{
+			Status:        types.MessageBountyStatusOpen,
+			CreatedBefore: expiresBefore,
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve expired bounties")
+	}
+
+	for _, messageBounty := range messageBounties {
+		s.expireBounty(api.ContextWithTeamId(ctx, messageBounty.TeamId), messageBounty)
+	}
+
+	return nil
+}
+
+// listAllBounties retrieves every page of bounties matching the filter, oldest first. Every page is retrieved before
+// any are handled as warning or expiring a bounty removes it from the list.
+func (s *BotStateService) listAllBounties(filter *types.ListMessageBountiesFilter) ([]*types.MessageBounty, error) {
+	var messageBounties []*types.MessageBounty
+	for pageToken := ""; ; {
+		page, nextPageToken, err := s.messageBountiesRepo.List(filter, 100, pageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		messageBounties = append(messageBounties, page...)
+		if nextPageToken == "" {
+			return messageBounties, nil
 		}
 
-		if err = s.channelAccountsRepo.ResetYearly(); err != nil {
-			return errors.Wrap(err, "failed to reset yearly")
+		pageToken = nextPageToken
+	}
+}
+
+// warnBountyExpiry lets the owner know in the bounty's thread that it's about to expire.
+func (s *BotStateService) warnBountyExpiry(ctx context.Context, messageBounty *types.MessageBounty) {
+	expires := messageBounty.Created.AsTime().AddDate(0, 0, s.config.BountyExpiryDays)
+
+	outcome := "its contributors will be refunded"
+	if s.config.ExpiredBounties == ExpiredBountiesAward {
+		outcome = "it will be awarded to whoever has claimed it (or refunded if nobody has)"
+	}
+
+	// The warning is only marked as sent if it was actually sent, otherwise it's retried on the next tickover.
+	if _, err := s.apiClient.SendMessage(ctx, &api.SlackPostMessageRequest{
+		Text:     "Heads up <@" + messageBounty.UserId + ">! This bounty expires on " + expires.Format("Mon, 2 Jan") + ", if it hasn't been awarded by then " + outcome + ".",
+		Channel:  messageBounty.ChannelId,
+		ThreadTs: messageBounty.MessageId,
+	}); err != nil {
+		s.log.WithError(err).WithField("message_id", messageBounty.MessageId).Error("Failed to warn the owner that their bounty is about to expire.")
+		return
+	}
+
+	messageBounty.ExpiryWarned = true
+	if _, err := s.messageBountiesRepo.Update(messageBounty); err != nil {
+		s.log.WithError(err).WithField("message_id", messageBounty.MessageId).Error("Failed to record that a bounty expiry warning was sent.")
+	}
+}
+
+// expireBounty closes the bounty and posts the outcome to its thread.
+func (s *BotStateService) expireBounty(ctx context.Context, messageBounty *types.MessageBounty) {
+	payouts, refunded, err := s.messageBountiesService.ExpireBounty(ctx, messageBounty)
+	if err != nil {
+		s.log.WithError(err).WithField("message_id", messageBounty.MessageId).Error("Failed to expire bounty.")
+		return
+	}
+
+	text := "This bounty has expired and " + fmt.Sprint(refunded) + " has been refunded to its contributors."
+	if len(payouts) > 0 {
+		var recipients []string
+		for _, payout := range payouts {
+			recipients = append(recipients, "<@"+payout.UserId+"> ("+fmt.Sprint(payout.Amount)+")")
 		}
 
-		// Set when the next tickover should occur.
-		botState.YearTickover = *timestamppb.New(botState.YearTickover.AsTime().Add(time.Hour * 8760))
+		text = "This bounty has expired and has been awarded to " + strings.Join(recipients, ", ") + "."
+	}
+
+	if _, err = s.apiClient.SendMessage(ctx, &api.SlackPostMessageRequest{
+		Text:     text,
+		Channel:  messageBounty.ChannelId,
+		ThreadTs: messageBounty.MessageId,
+	}); err != nil {
+		s.log.WithError(err).WithField("message_id", messageBounty.MessageId).Error("Failed to announce that a bounty has expired.")
 	}
 
-	// Update the bot's state before returning.
-	_, err = s.botStateRepo.Update(botState)
+	if len(payouts) > 0 {
+		if err = s.achievementsService.EvaluateAward(ctx, messageBounty, payouts); err != nil {
+			s.log.WithError(err).WithField("message_id", messageBounty.MessageId).Error("Failed to evaluate achievements for expired bounty.")
+		}
+	}
+}
+
+// maintainTransactionLedger removes transactions past their retention period and checks that the ledger still matches
+// each account's balance.
+func (s *BotStateService) maintainTransactionLedger(transactionsRepo db.AccountTransactionsRepo, now time.Time) error {
+	if s.config.TransactionRetentionDays > 0 {
+		pruned, err := transactionsRepo.Prune(now.AddDate(0, 0, -s.config.TransactionRetentionDays))
+		if err != nil {
+			return errors.Wrap(err, "failed to prune the transaction ledger")
+		}
+
+		s.log.Infof("Pruned %v transactions from the ledger.", pruned)
+	}
+
+	// A mismatch means a balance was changed without going through the repos (or while the ledger was disabled).
+	unreconciled, err := transactionsRepo.Unreconciled(100)
 	if err != nil {
-		return errors.Wrap(err, "failed to update bot_state when performing tickover.")
+		return errors.Wrap(err, "failed to reconcile the transaction ledger")
+	}
+
+	for _, account := range unreconciled {
+		s.log.WithFields(logrus.Fields{
+			"channel_account_id": account.ChannelAccountId,
+			"balance":            account.Balance,
+			"ledger_balance":     account.LedgerBalance,
+		}).Warn("Channel account balance does not match the transaction ledger.")
 	}
 
 	return nil
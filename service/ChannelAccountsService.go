@@ -7,9 +7,11 @@ import (
 	"github.com/buzzology/slack_bot/db"
 	"github.com/buzzology/slack_bot/service/api"
 	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
 )
 
 type IChannelAccounts interface {
+	GetOrCreate(ctx context.Context, userId string, channelId string) (*types.ChannelAccount, error)
 	DailyLeaderboard(ctx context.Context, channelId string) (*api.SlackBlocks, error)
 	WeeklyLeaderboard(ctx context.Context, channelId string) (*api.SlackBlocks, error)
 	YearlyLeaderboard(ctx context.Context, channelId string) (*api.SlackBlocks, error)
@@ -17,26 +19,96 @@ type IChannelAccounts interface {
 }
 
 type ChannelAccountsService struct {
-	config              *Config
-	channelAccountsRepo db.ChannelAccountsRepo
-	apiClient           api.SlackApiClient
+	config                 *Config
+	channelAccountsRepo    db.ChannelAccountsRepo
+	channelSettingsService *ChannelSettingsService
+	apiClient              api.SlackApiClient
 }
 
 func NewChannelAccountsService(
 	config *Config,
 	channelAccountsRepo db.ChannelAccountsRepo,
+	channelSettingsService *ChannelSettingsService,
 	apiClient api.SlackApiClient,
 ) *ChannelAccountsService {
 	return &ChannelAccountsService{
-		config:              config,
-		channelAccountsRepo: channelAccountsRepo,
-		apiClient:           apiClient,
+		config:                 config,
+		channelAccountsRepo:    channelAccountsRepo,
+		channelSettingsService: channelSettingsService,
+		apiClient:              apiClient,
 	}
 }
 
+// GetOrCreate returns the user's account in the channel, creating it if they don't have one yet. When the account
+// scope is "workspace" this is the user's workspace wallet. New accounts start with a day's income and users are
+// welcomed the first time they get an account.
+func (s *ChannelAccountsService) GetOrCreate(ctx context.Context, userId string, channelId string) (*types.ChannelAccount, error) {
+	channelAccounts, _, err := s.channelAccountsRepo.List(
+		&types.ListChannelAccountsFilter{
+			TeamId:    api.TeamIdFromContext(ctx),
+			UserId:    userId,
+			ChannelId: s.config.AccountChannelId(channelId),
+		},
+		2,
+		"",
+		"",
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check for an existing channel account: %v, %v", userId, channelId)
+	}
+
+	if len(channelAccounts) == 1 {
+		return channelAccounts[0], nil
+	}
+
+	if len(channelAccounts) > 1 {
+		return nil, fmt.Errorf("multiple channel accounts found for %v %v, this should not occur", userId, channelId)
+	}
+
+	channelConfig, err := s.channelSettingsService.Get(ctx, channelId)
+	if err != nil {
+		return nil, err
+	}
+
+	channelAccount, err := s.channelAccountsRepo.Create(
+		&types.ChannelAccount{
+			TeamId:    api.TeamIdFromContext(ctx),
+			UserId:    userId,
+			ChannelId: s.config.AccountChannelId(channelId),
+			Balance:   channelConfig.DailyIncome,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create a new channel account: %v, %v", userId, channelId)
+	}
+
+	// Check if this is their first account with the bot (none exist for other channels)
+	channelAccounts, _, _ = s.channelAccountsRepo.List(
+		&types.ListChannelAccountsFilter{
+			TeamId: api.TeamIdFromContext(ctx),
+			UserId: userId,
+		},
+		3,
+		"",
+		"",
+	)
+
+	// We check if it's only the new row and send a welcome message to the user if it is.
+	if len(channelAccounts) == 1 {
+		s.apiClient.SendMessage(
+			ctx,
+			&api.SlackPostMessageRequest{
+				Text:    "Welcome to SlackBounties! You'll start off with 1 point and can earn more by completing bounties. Use */bountyemotes* to see the full list of emotes, */bountyme* to see your details and */bountydaily* to see the current leaderboard. Check out the following page for more info: " + s.config.DocumentationUrl,
+				Channel: userId,
+			})
+	}
+
+	return channelAccount, nil
+}
+
 // DailyLeaderboard generates a leaderboard for the day's current earnings.
 func (s *ChannelAccountsService) DailyLeaderboard(ctx context.Context, channelId string) (*api.SlackBlocks, error) {
-	channelAccounts, err := s.channelAccountsRepo.LeadersToday(channelId, 30, 10)
+	channelAccounts, err := s.channelAccountsRepo.LeadersToday(api.TeamIdFromContext(ctx), s.config.AccountChannelId(channelId), 30, 10)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +133,7 @@ func (s *ChannelAccountsService) DailyLeaderboard(ctx context.Context, channelId
 
 // WeeklyLeaderboard generates a leaderboard for the day's current earnings.
 func (s *ChannelAccountsService) WeeklyLeaderboard(ctx context.Context, channelId string) (*api.SlackBlocks, error) {
-	channelAccounts, err := s.channelAccountsRepo.LeadersThisWeek(channelId, 30, 10)
+	channelAccounts, err := s.channelAccountsRepo.LeadersThisWeek(api.TeamIdFromContext(ctx), s.config.AccountChannelId(channelId), 30, 10)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +158,7 @@ func (s *ChannelAccountsService) WeeklyLeaderboard(ctx context.Context, channelI
 
 // YearlyLeaderboard generates a leaderboard for the day's current earnings.
 func (s *ChannelAccountsService) YearlyLeaderboard(ctx context.Context, channelId string) (*api.SlackBlocks, error) {
-	channelAccounts, err := s.channelAccountsRepo.LeadersThisYear(channelId, 30, 10)
+	channelAccounts, err := s.channelAccountsRepo.LeadersThisYear(api.TeamIdFromContext(ctx), s.config.AccountChannelId(channelId), 30, 10)
 	if err != nil {
 		return nil, err
 	}
@@ -111,7 +183,7 @@ func (s *ChannelAccountsService) YearlyLeaderboard(ctx context.Context, channelI
 
 // AllTimeLeaderboard generates a leaderboard for the day's current earnings.
 func (s *ChannelAccountsService) AllTimeLeaderboard(ctx context.Context, channelId string) (*api.SlackBlocks, error) {
-	channelAccounts, err := s.channelAccountsRepo.LeadersAllTime(channelId, 30, 10)
+	channelAccounts, err := s.channelAccountsRepo.LeadersAllTime(api.TeamIdFromContext(ctx), s.config.AccountChannelId(channelId), 30, 10)
 	if err != nil {
 		return nil, err
 	}
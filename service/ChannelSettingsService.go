@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/buzzology/slack_bot/db"
+	"github.com/buzzology/slack_bot/service/api"
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type IChannelSettingsService interface {
+	Defaults() *ChannelConfig
+	Get(ctx context.Context, channelId string) (*ChannelConfig, error)
+	GetOverrides(ctx context.Context, channelId string) (*types.ChannelSettings, error)
+	Save(ctx context.Context, settings *types.ChannelSettings) error
+	CanManage(ctx context.Context, channelId string, userId string) (bool, error)
+}
+
+// ChannelConfig is the economy settings that apply to a single channel. It's the config's values with the channel's
+// settings layered over the top.
+type ChannelConfig struct {
+	DailyDecay                int
+	DailyIncome               int
+	BoostReactions            []*BoostReactionValue
+	TaskCompletedByMeReaction string
+	ReleaseBountyReaction     string
+	MinBounty                 int
+	MaxBounty                 int
+	MaxBoostPerUser           int
+	DailySpendCap             int
+}
+
+// ChannelSettingsService resolves the settings for each channel and allows channel managers to change them.
+type ChannelSettingsService struct {
+	config              *Config
+	channelSettingsRepo db.ChannelSettingsRepo
+	apiClient           api.SlackApiClient
+	log                 *logrus.Logger
+}
+
+func NewChannelSettingsService(
+	config *Config,
+	channelSettingsRepo db.ChannelSettingsRepo,
+	apiClient api.SlackApiClient,
+	log *logrus.Logger,
+) *ChannelSettingsService {
+	return &ChannelSettingsService{
+		config:              config,
+		channelSettingsRepo: channelSettingsRepo,
+		apiClient:           apiClient,
+		log:                 log,
+	}
+}
+
+// Defaults returns the settings from the config, these are used by any channel that hasn't changed them.
+func (s *ChannelSettingsService) Defaults() *ChannelConfig {
+	return &ChannelConfig{
+		DailyDecay:                s.config.DailyDecay,
+		DailyIncome:               s.config.DailyIncome,
+		BoostReactions:            s.config.BoostReactions,
+		TaskCompletedByMeReaction: s.config.TaskCompletedByMeReaction,
+		ReleaseBountyReaction:     s.config.ReleaseBountyReaction,
+		MinBounty:                 s.config.MinBounty,
+		MaxBounty:                 s.config.MaxBounty,
+		MaxBoostPerUser:           s.config.MaxBoostPerUser,
+		DailySpendCap:             s.config.DailySpendCap,
+	}
+}
+
+// Get returns the settings that apply to the channel.
+func (s *ChannelSettingsService) Get(ctx context.Context, channelId string) (*ChannelConfig, error) {
+	channelConfig := s.Defaults()
+
+	settings, err := s.GetOverrides(ctx, channelId)
+	if err != nil {
+		return channelConfig, err
+	}
+
+	if settings == nil {
+		return channelConfig, nil
+	}
+
+	if settings.DailyDecay != nil {
+		channelConfig.DailyDecay = *settings.DailyDecay
+	}
+
+	if settings.DailyIncome != nil {
+		channelConfig.DailyIncome = *settings.DailyIncome
+	}
+
+	if settings.BoostReactions != "" {
+		boostReactions, err := ParseBoostReactions(settings.BoostReactions)
+		if err != nil {
+			return channelConfig, errors.Wrapf(err, "invalid boost reactions saved for channel: %v", channelId)
+		}
+
+		channelConfig.BoostReactions = boostReactions
+	}
+
+	if settings.TaskCompletedByMeReaction != "" {
+		channelConfig.TaskCompletedByMeReaction = settings.TaskCompletedByMeReaction
+	}
+
+	if settings.ReleaseBountyReaction != "" {
+		channelConfig.ReleaseBountyReaction = settings.ReleaseBountyReaction
+	}
+
+	if settings.MinBounty != nil {
+		channelConfig.MinBounty = *settings.MinBounty
+	}
+
+	if settings.MaxBounty != nil {
+		channelConfig.MaxBounty = *settings.MaxBounty
+	}
+
+	if settings.MaxBoostPerUser != nil {
+		channelConfig.MaxBoostPerUser = *settings.MaxBoostPerUser
+	}
+
+	if settings.DailySpendCap != nil {
+		channelConfig.DailySpendCap = *settings.DailySpendCap
+	}
+
+	return channelConfig, nil
+}
+
+// GetOverrides returns the settings that have been saved for the channel, nil if it uses the defaults.
+func (s *ChannelSettingsService) GetOverrides(ctx context.Context, channelId string) (*types.ChannelSettings, error) {
+	settings, err := s.channelSettingsRepo.Get(api.TeamIdFromContext(ctx), channelId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve settings for channel: %v", channelId)
+	}
+
+	return settings, nil
+}
+
+// Save replaces the settings for the channel.
+func (s *ChannelSettingsService) Save(ctx context.Context, settings *types.ChannelSettings) error {
+	if settings.BoostReactions != "" {
+		if _, err := ParseBoostReactions(settings.BoostReactions); err != nil {
+			return err
+		}
+	}
+
+	settings.TeamId = api.TeamIdFromContext(ctx)
+	if _, err := s.channelSettingsRepo.Upsert(settings); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CanManage checks whether the user can change the channel's settings. Workspace admins and owners can change the
+// settings of any channel, otherwise only the channel's creator can.
+func (s *ChannelSettingsService) CanManage(ctx context.Context, channelId string, userId string) (bool, error) {
+	user, err := s.apiClient.GetUserInfo(ctx, userId)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to retrieve user: %v", userId)
+	}
+
+	if user.IsAdmin || user.IsOwner {
+		return true, nil
+	}
+
+	channel, err := s.apiClient.GetConversationInfo(ctx, channelId)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to retrieve channel: %v", channelId)
+	}
+
+	return channel.Creator == userId, nil
+}
+
+// ParseBoostReactions parses a comma separated list of emote:value pairs, e.g. "dollar:1, moneybag:5".
+func ParseBoostReactions(value string) ([]*BoostReactionValue, error) {
+	var boostReactions []*BoostReactionValue
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+
+		// Allow the emote to be entered the way it's written in slack, e.g. ":dollar::1".
+		var fields []string
+		for _, part := range parts {
+			if part = strings.TrimSpace(part); part != "" {
+				fields = append(fields, part)
+			}
+		}
+
+		if len(fields) != 2 {
+			return nil, errors.Errorf("boost reactions must be entered as emote:value, e.g. dollar:1: %v", pair)
+		}
+
+		boostValue, err := strconv.Atoi(fields[1])
+		if err != nil || boostValue <= 0 {
+			return nil, errors.Errorf("boost values must be whole numbers greater than zero: %v", pair)
+		}
+
+		boostReactions = append(boostReactions, &BoostReactionValue{
+			Emote:      fields[0],
+			BoostValue: boostValue,
+		})
+	}
+
+	return boostReactions, nil
+}
+
+// FormatBoostReactions formats the boost reactions the same way ParseBoostReactions expects them.
+func FormatBoostReactions(boostReactions []*BoostReactionValue) string {
+	var pairs []string
+	for _, boostReaction := range boostReactions {
+		pairs = append(pairs, fmt.Sprintf("%v:%v", boostReaction.Emote, boostReaction.BoostValue))
+	}
+
+	return strings.Join(pairs, ", ")
+}
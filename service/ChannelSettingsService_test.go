@@ -0,0 +1,77 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBoostReactions(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []*BoostReactionValue
+		wantErr bool
+	}{
+		{
+			name:  "single reaction",
+			value: "dollar:1",
+			want:  []*BoostReactionValue{{Emote: "dollar", BoostValue: 1}},
+		},
+		{
+			name:  "multiple reactions with spaces",
+			value: "dollar:1, moneybag: 5",
+			want: []*BoostReactionValue{
+				{Emote: "dollar", BoostValue: 1},
+				{Emote: "moneybag", BoostValue: 5},
+			},
+		},
+		{
+			name:  "emote written the way it is in slack",
+			value: ":dollar::2",
+			want:  []*BoostReactionValue{{Emote: "dollar", BoostValue: 2}},
+		},
+		{
+			name:    "missing value",
+			value:   "dollar",
+			wantErr: true,
+		},
+		{
+			name:    "too many parts",
+			value:   "dollar:1:2",
+			wantErr: true,
+		},
+		{
+			name:    "value isn't a number",
+			value:   "dollar:one",
+			wantErr: true,
+		},
+		{
+			name:    "zero value",
+			value:   "dollar:0",
+			wantErr: true,
+		},
+		{
+			name:    "negative value",
+			value:   "dollar:-1",
+			wantErr: true,
+		},
+		{
+			name:    "trailing comma",
+			value:   "dollar:1,",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBoostReactions(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBoostReactions(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBoostReactions(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
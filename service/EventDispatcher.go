@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrEventQueueFull is returned when there is no room left in the queue for another event.
+	ErrEventQueueFull = errors.New("event queue is full")
+
+	// ErrEventDispatcherStopped is returned when an event is enqueued after draining has started.
+	ErrEventDispatcherStopped = errors.New("event dispatcher has been stopped")
+)
+
+// queueWarningPercentage is how full the queue can get before we start logging back-pressure warnings.
+const queueWarningPercentage = 80
+
+// EventJob is a unit of work processed by the dispatcher's workers.
+type EventJob func(ctx context.Context)
+
+type IEventDispatcher interface {
+	Start()
+	Enqueue(job EventJob) error
+	Drain(ctx context.Context) error
+	Stats() EventDispatcherStats
+}
+
+// EventDispatcherStats is a snapshot of the dispatcher's queue.
+type EventDispatcherStats struct {
+	Workers       int
+	QueueSize     int
+	QueueDepth    int
+	HighWaterMark int64
+	Enqueued      uint64
+	Processed     uint64
+	Rejected      uint64
+	Panics        uint64
+}
+
+// EventDispatcher processes events on a bounded pool of workers so that slack can be acknowledged immediately.
+type EventDispatcher struct {
+	queue   chan EventJob
+	workers int
+	log     *logrus.Logger
+
+	// ctx is handed to each job and is only cancelled if draining times out.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.RWMutex
+	stopped bool
+
+	enqueued      uint64
+	processed     uint64
+	rejected      uint64
+	panics        uint64
+	highWaterMark int64
+}
+
+func NewEventDispatcher(
+	workers int,
+	queueSize int,
+	log *logrus.Logger,
+) *EventDispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &EventDispatcher{
+		queue:   make(chan EventJob, queueSize),
+		workers: workers,
+		log:     log,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start launches the workers.
+func (d *EventDispatcher) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+}
+
+// Enqueue adds a job to the queue without blocking. An error is returned if the queue is full or stopped.
+func (d *EventDispatcher) Enqueue(job EventJob) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.stopped {
+		return ErrEventDispatcherStopped
+	}
+
+	select {
+	case d.queue <- job:
+		atomic.AddUint64(&d.enqueued, 1)
+	default:
+		atomic.AddUint64(&d.rejected, 1)
+		d.log.WithFields(d.statsFields()).Error("Event queue is full, rejecting event.")
+		return ErrEventQueueFull
+	}
+
+	// Track how close we're getting to the limit.
+	depth := int64(len(d.queue))
+	for {
+		highWaterMark := atomic.LoadInt64(&d.highWaterMark)
+		if depth <= highWaterMark || atomic.CompareAndSwapInt64(&d.highWaterMark, highWaterMark, depth) {
+			break
+		}
+	}
+
+	if depth*100 >= int64(cap(d.queue)*queueWarningPercentage) {
+		d.log.WithFields(d.statsFields()).Warn("Event queue is filling up, consider adding workers.")
+	}
+
+	return nil
+}
+
+// Drain stops accepting new jobs and waits for queued jobs to finish. Jobs still running when the context is done are cancelled.
+func (d *EventDispatcher) Drain(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.stopped {
+		d.stopped = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.cancel()
+		d.log.WithFields(d.statsFields()).Info("Event queue drained.")
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		d.log.WithFields(d.statsFields()).Error("Timed out draining the event queue.")
+		return errors.Wrap(ctx.Err(), "failed to drain event queue")
+	}
+}
+
+// Stats returns a snapshot of the dispatcher's counters.
+func (d *EventDispatcher) Stats() EventDispatcherStats {
+	return EventDispatcherStats{
+		Workers:       d.workers,
+		QueueSize:     cap(d.queue),
+		QueueDepth:    len(d.queue),
+		HighWaterMark: atomic.LoadInt64(&d.highWaterMark),
+		Enqueued:      atomic.LoadUint64(&d.enqueued),
+		Processed:     atomic.LoadUint64(&d.processed),
+		Rejected:      atomic.LoadUint64(&d.rejected),
+		Panics:        atomic.LoadUint64(&d.panics),
+	}
+}
+
+// work processes jobs until the queue is closed.
+func (d *EventDispatcher) work() {
+	defer d.wg.Done()
+
+	for job := range d.queue {
+		d.run(job)
+		atomic.AddUint64(&d.processed, 1)
+	}
+}
+
+// run executes a single job, a panic in one event shouldn't take down the worker.
+func (d *EventDispatcher) run(job EventJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddUint64(&d.panics, 1)
+			d.log.WithField("panic", r).Error("Recovered from a panic while processing an event.")
+		}
+	}()
+
+	job(d.ctx)
+}
+
+func (d *EventDispatcher) statsFields() logrus.Fields {
+	stats := d.Stats()
+
+	return logrus.Fields{
+		"workers":         stats.Workers,
+		"queue_size":      stats.QueueSize,
+		"queue_depth":     stats.QueueDepth,
+		"high_water_mark": stats.HighWaterMark,
+		"enqueued":        stats.Enqueued,
+		"processed":       stats.Processed,
+		"rejected":        stats.Rejected,
+		"panics":          stats.Panics,
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buzzology/slack_bot/db"
+	"github.com/buzzology/slack_bot/service/api"
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// installStateMaxAge is how long a user has to complete the install flow once it has been started.
+const installStateMaxAge = 10 * time.Minute
+
+// InstallationsService tracks the workspaces the bot has been installed to along with their bot tokens.
+type InstallationsService struct {
+	config            *Config
+	installationsRepo db.InstallationsRepo
+	log               *logrus.Logger
+
+	// tokens caches bot tokens by team id so that we don't hit the database for every api request.
+	tokens    map[string]string
+	tokensMux sync.RWMutex
+}
+
+func NewInstallationsService(
+	config *Config,
+	installationsRepo db.InstallationsRepo,
+	log *logrus.Logger,
+) *InstallationsService {
+	return &InstallationsService{
+		config:            config,
+		installationsRepo: installationsRepo,
+		log:               log,
+		tokens:            map[string]string{},
+	}
+}
+
+// BotToken returns the bot token for the workspace, an empty token is returned if it hasn't been installed.
+func (s *InstallationsService) BotToken(teamId string) (string, error) {
+	s.tokensMux.RLock()
+	token, ok := s.tokens[teamId]
+	s.tokensMux.RUnlock()
+
+	if ok {
+		return token, nil
+	}
+
+	installation, err := s.installationsRepo.Get(teamId)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to retrieve installation for team: %v", teamId)
+	}
+
+	// NOTE: We don't cache misses so that a workspace can be installed without a restart.
+	if installation == nil {
+		return "", nil
+	}
+
+	s.tokensMux.Lock()
+	s.tokens[teamId] = installation.BotToken
+	s.tokensMux.Unlock()
+
+	return installation.BotToken, nil
+}
+
+// SaveInstallation records the bot token returned when a workspace installs the bot.
+func (s *InstallationsService) SaveInstallation(accessResponse *api.SlackOAuthAccessResponse) (*types.Installation, error) {
+	if accessResponse.Team.Id == "" || accessResponse.AccessToken == "" {
+		return nil, errors.New("oauth access response did not include a team and bot token")
+	}
+
+	installation, err := s.installationsRepo.Upsert(
+		&types.Installation{
+			TeamId:      accessResponse.Team.Id,
+			TeamName:    accessResponse.Team.Name,
+			AppId:       accessResponse.AppId,
+			BotUserId:   accessResponse.BotUserId,
+			BotToken:    accessResponse.AccessToken,
+			Scope:       accessResponse.Scope,
+			InstalledBy: accessResponse.AuthedUser.Id,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to save installation for team: %v", accessResponse.Team.Id)
+	}
+
+	s.tokensMux.Lock()
+	s.tokens[installation.TeamId] = installation.BotToken
+	s.tokensMux.Unlock()
+
+	return installation, nil
+}
+
+// GenerateInstallState returns a signed state value used to protect the oauth redirect from forgery.
+func (s *InstallationsService) GenerateInstallState(now time.Time) string {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	return timestamp + "." + s.signInstallState(timestamp)
+}
+
+// ValidateInstallState ensures that the state was generated by us and hasn't expired.
+func (s *InstallationsService) ValidateInstallState(state string, now time.Time) error {
+	parts := strings.SplitN(state, ".", 2)
+	if len(parts) != 2 {
+		return errors.New("malformed install state")
+	}
+
+	if !hmac.Equal([]byte(s.signInstallState(parts[0])), []byte(parts[1])) {
+		return errors.New("install state signature does not match")
+	}
+
+	issued, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return errors.New("malformed install state timestamp")
+	}
+
+	if now.Sub(time.Unix(issued, 0)) > installStateMaxAge {
+		return errors.New("install state has expired")
+	}
+
+	return nil
+}
+
+func (s *InstallationsService) signInstallState(timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(s.config.ApiConfig.ClientSecret))
+	mac.Write([]byte("install:" + timestamp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,779 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/buzzology/slack_bot/db"
+	"github.com/buzzology/slack_bot/service/api"
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrBountyBelowMinimum is returned when removing a boost would leave the bounty below the channel's minimum.
+var ErrBountyBelowMinimum = errors.New("bounty would be below the channel's minimum")
+
+type IMessageBountiesService interface {
+	CancelBounty(ctx context.Context, messageBounty *types.MessageBounty) ([]*types.BountyContributor, int, error)
+	HandOverBounty(ctx context.Context, messageBounty *types.MessageBounty) (string, error)
+	RefundBoost(ctx context.Context, messageBounty *types.MessageBounty, userId string, reaction string) (int, error)
+	AwardBounty(ctx context.Context, messageBounty *types.MessageBounty, payouts []*types.BountyPayout) error
+	ApplyFastReviewBonuses(ctx context.Context, messageBounty *types.MessageBounty, payouts []*types.BountyPayout) error
+	ExpireBounty(ctx context.Context, messageBounty *types.MessageBounty) ([]*types.BountyPayout, int, error)
+	Contributors(ctx context.Context, messageBounty *types.MessageBounty) ([]*types.BountyContributor, error)
+	Claimants(ctx context.Context, messageBounty *types.MessageBounty) ([]string, error)
+	DefaultRecipients(ctx context.Context, messageBounty *types.MessageBounty) ([]string, error)
+	Payouts(ctx context.Context, messageBounty *types.MessageBounty, userIds []string, shares []int) ([]*types.BountyPayout, error)
+	BoostLimitMessage(ctx context.Context, tx *sql.Tx, channelConfig *ChannelConfig, channelId string, messageId string, userId string, currentBounty int, boostAmount int) (string, error)
+}
+
+type MessageBountiesService struct {
+	config                         *Config
+	messageBountiesRepo            db.MessageBountiesRepo
+	messageBountyContributionsRepo db.MessageBountyContributionsRepo
+	channelAccountsRepo            db.ChannelAccountsRepo
+	bountyClaimsRepo               db.BountyClaimsRepo
+	channelAccountsService         *ChannelAccountsService
+	channelSettingsService         *ChannelSettingsService
+	botStateRepo                   db.BotStateRepo
+	unitOfWork                     *db.UnitOfWork
+	log                            *logrus.Logger
+}
+
+func NewMessageBountiesService(
+	config *Config,
+	messageBountiesRepo db.MessageBountiesRepo,
+	messageBountyContributionsRepo db.MessageBountyContributionsRepo,
+	channelAccountsRepo db.ChannelAccountsRepo,
+	bountyClaimsRepo db.BountyClaimsRepo,
+	channelAccountsService *ChannelAccountsService,
+	channelSettingsService *ChannelSettingsService,
+	botStateRepo db.BotStateRepo,
+	unitOfWork *db.UnitOfWork,
+	log *logrus.Logger,
+) *MessageBountiesService {
+	return &MessageBountiesService{
+		config:                         config,
+		messageBountiesRepo:            messageBountiesRepo,
+		messageBountyContributionsRepo: messageBountyContributionsRepo,
+		channelAccountsRepo:            channelAccountsRepo,
+		bountyClaimsRepo:               bountyClaimsRepo,
+		channelAccountsService:         channelAccountsService,
+		channelSettingsService:         channelSettingsService,
+		botStateRepo:                   botStateRepo,
+		unitOfWork:                     unitOfWork,
+		log:                            log,
+	}
+}
+
+// CancelBounty closes an open bounty and refunds each of its contributors. Who was refunded, largest first, and the
+// total refunded are returned.
+func (s *MessageBountiesService) CancelBounty(ctx context.Context, messageBounty *types.MessageBounty) ([]*types.BountyContributor, int, error) {
+	return s.closeAndRefundBounty(ctx, messageBounty, types.MessageBountyStatusCancelled)
+}
+
+// closeAndRefundBounty closes an open bounty with the status and refunds each of its contributors. Who was refunded
+// and the total refunded are returned.
+func (s *MessageBountiesService) closeAndRefundBounty(
+	ctx context.Context,
+	messageBounty *types.MessageBounty,
+	status int,
+) ([]*types.BountyContributor, int, error) {
+	if messageBounty.Status != types.MessageBountyStatusOpen {
+		return nil, 0, errors.Errorf("only open bounties can be cancelled: %v, %v", messageBounty.MessageId, messageBounty.Status)
+	}
+
+	var refunded int
+	var contributors []*types.BountyContributor
+	if err := s.unitOfWork.Run(func(tx *sql.Tx) error {
+		// Close the bounty first so that it can't be boosted or awarded while we're refunding. This fails if it was
+		// closed or boosted since it was read, rolling back the refunds.
+		if err := s.messageBountiesRepo.WithTx(tx).Close(messageBounty, status, messageBounty.AwardedTo); err != nil {
+			return errors.Wrapf(err, "failed to close bounty: %v", messageBounty.MessageId)
+		}
+
+		contributions, err := s.messageBountyContributionsRepo.WithTx(tx).ListActive(
+			api.TeamIdFromContext(ctx),
+			messageBounty.MessageId,
+			messageBounty.ChannelId,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list contributions for closed bounty: %v", messageBounty.MessageId)
+		}
+
+		for _, contribution := range contributions {
+			if err = s.refundContribution(tx, contribution); err != nil {
+				return err
+			}
+
+			refunded += contribution.Amount
+		}
+
+		contributors = totalContributions(contributions)
+		return nil
+	}); err != nil {
+		return nil, 0, err
+	}
+
+	messageBounty.Status = status
+
+	// Bounties boosted before contributions were recorded can't be refunded.
+	if refunded < messageBounty.CurrentBounty {
+		s.log.WithFields(logrus.Fields{
+			"message_id": messageBounty.MessageId,
+			"channel_id": messageBounty.ChannelId,
+			"bounty":     messageBounty.CurrentBounty,
+			"refunded":   refunded,
+		}).Warn("Closed bounty has contributions that could not be refunded.")
+	}
+
+	return contributors, refunded, nil
+}
+
+// totalContributions adds up what each user contributed, largest first with ties going to whoever contributed first
+// in the same way as Contributors.
+func totalContributions(contributions []*types.MessageBountyContribution) []*types.BountyContributor {
+	var contributors []*types.BountyContributor
+	totals := map[string]*types.BountyContributor{}
+	firstIds := map[string]int{}
+	for _, contribution := range contributions {
+		if contributor, ok := totals[contribution.UserId]; ok {
+			contributor.Amount += contribution.Amount
+		} else {
+			totals[contribution.UserId] = &types.BountyContributor{UserId: contribution.UserId, Amount: contribution.Amount}
+			contributors = append(contributors, totals[contribution.UserId])
+		}
+
+		if firstId, ok := firstIds[contribution.UserId]; !ok || contribution.Id < firstId {
+			firstIds[contribution.UserId] = contribution.Id
+		}
+	}
+
+	sort.SliceStable(contributors, func(i, j int) bool {
+		if contributors[i].Amount != contributors[j].Amount {
+			return contributors[i].Amount > contributors[j].Amount
+		}
+
+		return firstIds[contributors[i].UserId] < firstIds[contributors[j].UserId]
+	})
+
+	return contributors
+}
+
+// HandOverBounty makes the largest remaining contributor the owner of the bounty so that they can award it. The new
+// owner is returned, an empty user id is returned if nobody else has contributed and the bounty was left as is.
+func (s *MessageBountiesService) HandOverBounty(ctx context.Context, messageBounty *types.MessageBounty) (string, error) {
+	if messageBounty.Status != types.MessageBountyStatusOpen {
+		return "", errors.Errorf("only open bounties can be handed over: %v, %v", messageBounty.MessageId, messageBounty.Status)
+	}
+
+	contributions, err := s.messageBountyContributionsRepo.ListActive(
+		api.TeamIdFromContext(ctx),
+		messageBounty.MessageId,
+		messageBounty.ChannelId,
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list contributions for bounty: %v", messageBounty.MessageId)
+	}
+
+	// Total up what each of the other contributors has put towards the bounty. Whoever has claimed it is skipped as
+	// they would be able to award it to themselves.
+	totals := map[string]int{}
+	accountIds := map[string]int{}
+	for _, contribution := range contributions {
+		if contribution.UserId == messageBounty.UserId || contribution.UserId == messageBounty.AwardedTo {
+			continue
+		}
+
+		totals[contribution.UserId] += contribution.Amount
+		accountIds[contribution.UserId] = contribution.ChannelAccountId
+	}
+
+	var newOwner string
+	for userId, total := range totals {
+		if newOwner != "" && (total < totals[newOwner] || (total == totals[newOwner] && userId > newOwner)) {
+			continue
+		}
+
+		// Contributors that have also left can't award the bounty either.
+		channelAccounts, _, err := s.channelAccountsRepo.List(
+			&types.ListChannelAccountsFilter{
+				Id: accountIds[userId],
+			},
+			1,
+			"",
+			"",
+		)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to retrieve contributor's channel account: %v", accountIds[userId])
+		}
+
+		if len(channelAccounts) == 0 || channelAccounts[0].Frozen {
+			continue
+		}
+
+		newOwner = userId
+	}
+
+	if newOwner == "" {
+		return "", nil
+	}
+
+	// The hand over only applies while the bounty is still open and owned by the departed user.
+	if err = s.unitOfWork.Run(func(tx *sql.Tx) error {
+		if err := s.messageBountiesRepo.WithTx(tx).HandOver(messageBounty, newOwner); err != nil {
+			return errors.Wrapf(err, "failed to hand over bounty: %v", messageBounty.MessageId)
+		}
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	messageBounty.UserId = newOwner
+	return newOwner, nil
+}
+
+// RefundBoost reverses the user's most recent boost with the reaction, reducing the bounty and returning the points to
+// their account. The amount refunded is returned, zero if there was no boost to refund.
+func (s *MessageBountiesService) RefundBoost(
+	ctx context.Context,
+	messageBounty *types.MessageBounty,
+	userId string,
+	reaction string,
+) (int, error) {
+	// Awarded bounties have already been paid out and cancelled bounties have already been refunded.
+	if messageBounty.Status != types.MessageBountyStatusOpen {
+		return 0, errors.Errorf("only boosts on open bounties can be refunded: %v, %v", messageBounty.MessageId, messageBounty.Status)
+	}
+
+	contributions, _, err := s.messageBountyContributionsRepo.List(
+		&types.ListMessageBountyContributionsFilter{
+			TeamId:    api.TeamIdFromContext(ctx),
+			MessageId: messageBounty.MessageId,
+			ChannelId: messageBounty.ChannelId,
+			UserId:    userId,
+			Reaction:  reaction,
+			Status:    types.MessageBountyContributionStatusActive,
+		},
+		1,
+		"",
+	)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to list contributions for bounty: %v", messageBounty.MessageId)
+	}
+
+	// The boost may have been rejected (e.g. their balance was too low) so there's nothing to refund.
+	if len(contributions) == 0 {
+		return 0, nil
+	}
+
+	channelConfig, err := s.channelSettingsService.Get(ctx, messageBounty.ChannelId)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = s.unitOfWork.Run(func(tx *sql.Tx) error {
+		// Boosting by a negative amount removes the contribution from the bounty.
+		if err := s.messageBountiesRepo.WithTx(tx).BoostBounty(messageBounty.TeamId, messageBounty.ChannelId, messageBounty.MessageId, -contributions[0].Amount); err != nil {
+			return errors.Wrapf(err, "failed to reduce bounty: %v", messageBounty.MessageId)
+		}
+
+		// The bounty is locked by the boost so this includes any boosts made since it was read. Removing every boost
+		// is allowed as that leaves nothing to award.
+		if channelConfig.MinBounty > 0 {
+			messageBounties, _, err := s.messageBountiesRepo.WithTx(tx).List(
+				&types.ListMessageBountiesFilter{
+					TeamId:    messageBounty.TeamId,
+					ChannelId: messageBounty.ChannelId,
+					MessageId: messageBounty.MessageId,
+				},
+				1,
+				"",
+			)
+			if err != nil {
+				return errors.Wrapf(err, "failed to retrieve reduced bounty: %v", messageBounty.MessageId)
+			}
+
+			if len(messageBounties) > 0 && messageBounties[0].CurrentBounty > 0 && messageBounties[0].CurrentBounty < channelConfig.MinBounty {
+				return ErrBountyBelowMinimum
+			}
+		}
+
+		return s.refundContribution(tx, contributions[0])
+	}); err != nil {
+		// The same boost was refunded at the same time, e.g. a retried event, so there's nothing left to refund.
+		if errors.Cause(err) == db.ErrContributionNotActive {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	messageBounty.CurrentBounty -= contributions[0].Amount
+
+	return contributions[0].Amount, nil
+}
+
+// AwardBounty pays out the bounty to each of the recipients and marks it as awarded. The recipient with the largest
+// share is recorded as the one the bounty was awarded to.
+func (s *MessageBountiesService) AwardBounty(
+	ctx context.Context,
+	messageBounty *types.MessageBounty,
+	payouts []*types.BountyPayout,
+) error {
+	return s.closeAndPayOutBounty(ctx, messageBounty, payouts, types.MessageBountyStatusAwarded)
+}
+
+// closeAndPayOutBounty closes an open bounty with the status and pays out each of the recipients.
+func (s *MessageBountiesService) closeAndPayOutBounty(
+	ctx context.Context,
+	messageBounty *types.MessageBounty,
+	payouts []*types.BountyPayout,
+	status int,
+) error {
+	if messageBounty.Status != types.MessageBountyStatusOpen {
+		return errors.Errorf("only open bounties can be awarded: %v, %v", messageBounty.MessageId, messageBounty.Status)
+	}
+
+	if len(payouts) == 0 {
+		return errors.Errorf("no recipients provided for bounty: %v", messageBounty.MessageId)
+	}
+
+	awardedTo := payouts[0]
+	for _, payout := range payouts {
+		if payout.Amount > awardedTo.Amount {
+			awardedTo = payout
+		}
+	}
+
+	// The bonus cap is per bot day.
+	var dayStart time.Time
+	if s.config.FastReviewBonusDailyCap > 0 {
+		var err error
+		if dayStart, err = s.botDayStart(); err != nil {
+			return err
+		}
+	}
+
+	// The bounty is only marked as awarded if every share is paid out.
+	if err := s.unitOfWork.Run(func(tx *sql.Tx) error {
+		// This fails if the bounty was closed or boosted since it was read, so the payouts can't be made twice or
+		// from a stale amount.
+		if err := s.messageBountiesRepo.WithTx(tx).Close(messageBounty, status, awardedTo.UserId); err != nil {
+			return errors.Wrapf(err, "failed to award bounty: %v", messageBounty.MessageId)
+		}
+
+		for _, payout := range payouts {
+			if err := s.channelAccountsRepo.WithTx(tx).Award(payout.ChannelAccountId, payout.Amount, messageBounty.MessageId); err != nil {
+				return errors.Wrapf(err, "failed to award share of bounty to user: %v, %v", messageBounty.MessageId, payout.UserId)
+			}
+
+			if err := s.capFastReviewBonus(ctx, tx, messageBounty, payout, dayStart); err != nil {
+				return errors.Wrapf(err, "failed to check the fast review bonus cap for user: %v, %v", messageBounty.MessageId, payout.UserId)
+			}
+
+			if payout.Bonus > 0 {
+				if err := s.channelAccountsRepo.WithTx(tx).AwardBonus(payout.ChannelAccountId, payout.Bonus, messageBounty.MessageId); err != nil {
+					return errors.Wrapf(err, "failed to pay fast review bonus to user: %v, %v", messageBounty.MessageId, payout.UserId)
+				}
+			}
+
+			// Recipients picked by the owner may not have claimed the bounty so their claim is created here.
+			if err := s.bountyClaimsRepo.WithTx(tx).Upsert(&types.BountyClaim{
+				TeamId:    api.TeamIdFromContext(ctx),
+				MessageId: messageBounty.MessageId,
+				ChannelId: messageBounty.ChannelId,
+				UserId:    payout.UserId,
+				Status:    types.BountyClaimStatusAwarded,
+				Amount:    payout.Amount,
+				Bonus:     payout.Bonus,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	messageBounty.Status = status
+	messageBounty.AwardedTo = awardedTo.UserId
+
+	return nil
+}
+
+// ApplyFastReviewBonuses sets the bonus of each payout whose recipient claimed the bounty within one of the fast review
+// windows. Recipients that never claimed it are treated as claiming it now. Bonuses are limited by the daily cap when
+// the bounty is awarded.
+func (s *MessageBountiesService) ApplyFastReviewBonuses(
+	ctx context.Context,
+	messageBounty *types.MessageBounty,
+	payouts []*types.BountyPayout,
+) error {
+	if len(s.config.FastReviewBonuses) == 0 {
+		return nil
+	}
+
+	claims, _, err := s.bountyClaimsRepo.List(
+		&types.ListBountyClaimsFilter{
+			TeamId:    api.TeamIdFromContext(ctx),
+			MessageId: messageBounty.MessageId,
+			ChannelId: messageBounty.ChannelId,
+			Status:    types.BountyClaimStatusActive,
+		},
+		100,
+		"",
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list claims for bounty: %v", messageBounty.MessageId)
+	}
+
+	// A claim that was retracted and made again is measured from when it was made again.
+	claimed := map[string]time.Time{}
+	for _, claim := range claims {
+		claimed[claim.UserId] = claim.ClaimedAt.AsTime()
+	}
+
+	for _, payout := range payouts {
+		claimedAt, ok := claimed[payout.UserId]
+		if !ok {
+			claimedAt = time.Now()
+		}
+
+		multiplier := FastReviewMultiplier(s.config.FastReviewBonuses, claimedAt.Sub(messageBounty.Created.AsTime()))
+		if bonus := int(float64(payout.Amount)*multiplier) - payout.Amount; bonus > 0 {
+			payout.Bonus = bonus
+		}
+	}
+
+	return nil
+}
+
+// capFastReviewBonus limits the payout's bonus to what's left of the recipient's daily cap. It should be called in the
+// award transaction once the recipient's account has been updated, as that locks it so their bonuses are totalled one
+// award at a time.
+func (s *MessageBountiesService) capFastReviewBonus(
+	ctx context.Context,
+	tx *sql.Tx,
+	messageBounty *types.MessageBounty,
+	payout *types.BountyPayout,
+	dayStart time.Time,
+) error {
+	if payout.Bonus <= 0 || s.config.FastReviewBonusDailyCap <= 0 {
+		return nil
+	}
+
+	paid, err := s.bountyClaimsRepo.WithTx(tx).BonusSince(api.TeamIdFromContext(ctx), messageBounty.ChannelId, payout.UserId, dayStart)
+	if err != nil {
+		return err
+	}
+
+	if remaining := s.config.FastReviewBonusDailyCap - paid; payout.Bonus > remaining {
+		payout.Bonus = remaining
+	}
+
+	if payout.Bonus < 0 {
+		payout.Bonus = 0
+	}
+
+	return nil
+}
+
+// botDayStart returns when the current bot day started, daily caps reset alongside the daily leaderboard.
+func (s *MessageBountiesService) botDayStart() (time.Time, error) {
+	botState, err := s.botStateRepo.Get()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to retrieve bot state")
+	}
+
+	if botState == nil {
+		return time.Now().Add(-time.Hour * 24), nil
+	}
+
+	return botState.DayTickover.AsTime().Add(-time.Hour * 24), nil
+}
+
+// FastReviewMultiplier returns the highest multiplier of the bonuses whose window the elapsed time falls within, 1 if
+// there are none.
+func FastReviewMultiplier(bonuses []*FastReviewBonus, elapsed time.Duration) float64 {
+	multiplier := 1.0
+	for _, bonus := range bonuses {
+		if elapsed <= time.Minute*time.Duration(bonus.WithinMinutes) && bonus.Multiplier > multiplier {
+			multiplier = bonus.Multiplier
+		}
+	}
+
+	return multiplier
+}
+
+// ExpireBounty closes a bounty that has been open too long. Depending on the config it's either split equally between
+// its claimants or refunded to its contributors. The payouts are returned if it was awarded, otherwise the total
+// refunded.
+func (s *MessageBountiesService) ExpireBounty(ctx context.Context, messageBounty *types.MessageBounty) ([]*types.BountyPayout, int, error) {
+	if s.config.ExpiredBounties == ExpiredBountiesAward {
+		claimants, err := s.DefaultRecipients(ctx, messageBounty)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		payouts, err := s.Payouts(ctx, messageBounty, claimants, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// Bounties that nobody has claimed are refunded instead.
+		if len(payouts) > 0 {
+			if err = s.closeAndPayOutBounty(ctx, messageBounty, payouts, types.MessageBountyStatusExpired); err != nil {
+				return nil, 0, err
+			}
+
+			return payouts, 0, nil
+		}
+	}
+
+	_, refunded, err := s.closeAndRefundBounty(ctx, messageBounty, types.MessageBountyStatusExpired)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return nil, refunded, nil
+}
+
+// Claimants returns the users with an active claim on the bounty in the order they claimed it. The owner is skipped
+// as they can't award the bounty to themselves.
+func (s *MessageBountiesService) Claimants(ctx context.Context, messageBounty *types.MessageBounty) ([]string, error) {
+	claims, _, err := s.bountyClaimsRepo.List(
+		&types.ListBountyClaimsFilter{
+			TeamId:    api.TeamIdFromContext(ctx),
+			MessageId: messageBounty.MessageId,
+			ChannelId: messageBounty.ChannelId,
+			Status:    types.BountyClaimStatusActive,
+		},
+		100,
+		"",
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list claims for bounty: %v", messageBounty.MessageId)
+	}
+
+	var claimants []string
+	for _, claim := range claims {
+		if claim.UserId != messageBounty.UserId {
+			claimants = append(claimants, claim.UserId)
+		}
+	}
+
+	return claimants, nil
+}
+
+// DefaultRecipients returns who the bounty is awarded to when nobody has been picked, i.e. its claimants.
+func (s *MessageBountiesService) DefaultRecipients(ctx context.Context, messageBounty *types.MessageBounty) ([]string, error) {
+	claimants, err := s.Claimants(ctx, messageBounty)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bounties claimed before claims were recorded only have the most recent claimant.
+	if len(claimants) == 0 && messageBounty.AwardedTo != "" && messageBounty.AwardedTo != messageBounty.UserId {
+		claimants = append(claimants, messageBounty.AwardedTo)
+	}
+
+	return claimants, nil
+}
+
+// Payouts splits the bounty between the users according to their shares, equally if there isn't a share for each of
+// them. Users left without any of the bounty aren't paid, so they aren't recorded as having been awarded it.
+func (s *MessageBountiesService) Payouts(
+	ctx context.Context,
+	messageBounty *types.MessageBounty,
+	userIds []string,
+	shares []int,
+) ([]*types.BountyPayout, error) {
+	if len(shares) != len(userIds) {
+		shares = make([]int, len(userIds))
+		for i := range shares {
+			shares[i] = 1
+		}
+	}
+
+	amounts := SplitBounty(messageBounty.CurrentBounty, shares)
+
+	var payouts []*types.BountyPayout
+	for i, userId := range userIds {
+		if shares[i] == 0 || (amounts[i] == 0 && messageBounty.CurrentBounty > 0) {
+			continue
+		}
+
+		channelAccount, err := s.channelAccountsService.GetOrCreate(ctx, userId, messageBounty.ChannelId)
+		if err != nil {
+			return nil, err
+		}
+
+		payouts = append(payouts, &types.BountyPayout{
+			UserId:           userId,
+			ChannelAccountId: channelAccount.Id,
+			Amount:           amounts[i],
+		})
+	}
+
+	return payouts, nil
+}
+
+// Contributors returns the total each user has put towards the bounty, largest first. Refunded boosts aren't included.
+func (s *MessageBountiesService) Contributors(ctx context.Context, messageBounty *types.MessageBounty) ([]*types.BountyContributor, error) {
+	contributors, err := s.messageBountyContributionsRepo.Contributors(api.TeamIdFromContext(ctx), messageBounty.MessageId, messageBounty.ChannelId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list contributors for bounty: %v", messageBounty.MessageId)
+	}
+
+	return contributors, nil
+}
+
+// BoostLimitMessage checks the boost against the channel's limits on bounty sizes and spending. It returns a message
+// explaining the limit that would be broken, or an empty string if the boost is allowed. The message id is empty when
+// the message doesn't have a bounty yet and the current bounty is the amount before the boost. When called in the boost
+// transaction, once the account has been charged and the bounty boosted, the totals include any concurrent boosts.
+func (s *MessageBountiesService) BoostLimitMessage(
+	ctx context.Context,
+	tx *sql.Tx,
+	channelConfig *ChannelConfig,
+	channelId string,
+	messageId string,
+	userId string,
+	currentBounty int,
+	boostAmount int,
+) (string, error) {
+	contributionsRepo := s.messageBountyContributionsRepo
+	if tx != nil {
+		contributionsRepo = contributionsRepo.WithTx(tx)
+	}
+
+	// Only what's been put towards bounties counts towards the cap, tips don't.
+	var spentToday int
+	if channelConfig.DailySpendCap > 0 {
+		dayStart, err := s.botDayStart()
+		if err != nil {
+			return "", err
+		}
+
+		if spentToday, err = contributionsRepo.SumSince(api.TeamIdFromContext(ctx), channelId, userId, dayStart); err != nil {
+			return "", err
+		}
+	}
+
+	// Only the user's active contributions count, refunded ones have been returned.
+	var contributed int
+	if channelConfig.MaxBoostPerUser > 0 && messageId != "" {
+		contributors, err := contributionsRepo.Contributors(api.TeamIdFromContext(ctx), messageId, channelId)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to retrieve contributions for bounty: %v", messageId)
+		}
+
+		for _, contributor := range contributors {
+			if contributor.UserId == userId {
+				contributed = contributor.Amount
+			}
+		}
+	}
+
+	return boostLimitMessage(channelConfig, spentToday, currentBounty, contributed, boostAmount), nil
+}
+
+// boostLimitMessage checks the boost against the channel's limits given what the user has already put towards bounties
+// in the channel today and towards this bounty.
+func boostLimitMessage(channelConfig *ChannelConfig, spentToday int, currentBounty int, contributed int, boostAmount int) string {
+	if channelConfig.DailySpendCap > 0 && spentToday+boostAmount > channelConfig.DailySpendCap {
+		remaining := channelConfig.DailySpendCap - spentToday
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		return fmt.Sprintf("You can put up to %v towards bounties in this channel each day and have %v left today.", channelConfig.DailySpendCap, remaining)
+	}
+
+	if channelConfig.MinBounty > 0 && currentBounty+boostAmount < channelConfig.MinBounty {
+		return fmt.Sprintf("Bounties in this channel need to be at least %v, this one would only be %v.", channelConfig.MinBounty, currentBounty+boostAmount)
+	}
+
+	if channelConfig.MaxBounty > 0 && currentBounty+boostAmount > channelConfig.MaxBounty {
+		return fmt.Sprintf("Bounties in this channel can be at most %v, this one is already %v.", channelConfig.MaxBounty, currentBounty)
+	}
+
+	if channelConfig.MaxBoostPerUser > 0 && contributed+boostAmount > channelConfig.MaxBoostPerUser {
+		return fmt.Sprintf(
+			"You can contribute up to %v to each bounty in this channel and have already contributed %v to this one.",
+			channelConfig.MaxBoostPerUser,
+			contributed,
+		)
+	}
+
+	return ""
+}
+
+// FormatContributors lists the contributors along with what they put towards the bounty, e.g. "<@U1> (3), <@U2> (1)".
+func FormatContributors(contributors []*types.BountyContributor) string {
+	var formatted []string
+	for _, contributor := range contributors {
+		formatted = append(formatted, fmt.Sprintf("<@%v> (%v)", contributor.UserId, contributor.Amount))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// SplitBounty divides the total between recipients in proportion to their shares. Any remainder left after rounding
+// down is handed out one point at a time to those with the largest fractional part, earlier recipients winning ties.
+func SplitBounty(total int, shares []int) []int {
+	amounts := make([]int, len(shares))
+
+	var totalShares int
+	for _, share := range shares {
+		totalShares += share
+	}
+
+	if totalShares <= 0 || total <= 0 {
+		return amounts
+	}
+
+	remainders := make([]int, len(shares))
+	remaining := total
+	for i, share := range shares {
+		amounts[i] = total * share / totalShares
+		remainders[i] = total * share % totalShares
+		remaining -= amounts[i]
+	}
+
+	for ; remaining > 0; remaining-- {
+		largest := 0
+		for i := range remainders {
+			if remainders[i] > remainders[largest] {
+				largest = i
+			}
+		}
+
+		amounts[largest]++
+		remainders[largest] = -1
+	}
+
+	return amounts
+}
+
+// refundContribution returns the points to the contributor and marks the contribution as refunded.
+func (s *MessageBountiesService) refundContribution(tx *sql.Tx, contribution *types.MessageBountyContribution) error {
+	// The contribution is marked first as this fails if it has already been refunded, rolling back the refund.
+	if err := s.messageBountyContributionsRepo.WithTx(tx).UpdateStatus(contribution.Id, types.MessageBountyContributionStatusRefunded); err != nil {
+		return errors.Wrapf(err, "failed to mark contribution as refunded: %v", contribution.Id)
+	}
+
+	if err := s.channelAccountsRepo.WithTx(tx).Refund(contribution.ChannelAccountId, contribution.Amount, contribution.MessageId); err != nil {
+		return errors.Wrapf(err, "failed to refund contribution: %v", contribution.Id)
+	}
+
+	return nil
+}
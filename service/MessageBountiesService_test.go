@@ -0,0 +1,291 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/buzzology/slack_bot/types"
+)
+
+func TestSplitBounty(t *testing.T) {
+	tests := []struct {
+		name   string
+		total  int
+		shares []int
+		want   []int
+	}{
+		{
+			name:   "no recipients",
+			total:  10,
+			shares: []int{},
+			want:   []int{},
+		},
+		{
+			name:   "single recipient",
+			total:  10,
+			shares: []int{1},
+			want:   []int{10},
+		},
+		{
+			name:   "even split",
+			total:  10,
+			shares: []int{1, 1},
+			want:   []int{5, 5},
+		},
+		{
+			name:   "weighted split",
+			total:  12,
+			shares: []int{2, 1},
+			want:   []int{8, 4},
+		},
+		{
+			name:   "remainder tie goes to the earliest recipient",
+			total:  10,
+			shares: []int{1, 1, 1},
+			want:   []int{4, 3, 3},
+		},
+		{
+			name:   "remainder goes to the largest fractional part",
+			total:  7,
+			shares: []int{1, 2},
+			want:   []int{2, 5},
+		},
+		{
+			name:   "zero share gets nothing",
+			total:  5,
+			shares: []int{1, 0, 1},
+			want:   []int{3, 0, 2},
+		},
+		{
+			name:   "all zero shares",
+			total:  10,
+			shares: []int{0, 0},
+			want:   []int{0, 0},
+		},
+		{
+			name:   "total smaller than the number of recipients",
+			total:  2,
+			shares: []int{1, 1, 1},
+			want:   []int{1, 1, 0},
+		},
+		{
+			name:   "empty bounty",
+			total:  0,
+			shares: []int{1, 1},
+			want:   []int{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitBounty(tt.total, tt.shares)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitBounty(%v, %v) = %v, want %v", tt.total, tt.shares, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFastReviewMultiplier(t *testing.T) {
+	bonuses := []*FastReviewBonus{
+		{WithinMinutes: 60, Multiplier: 1.5},
+		{WithinMinutes: 15, Multiplier: 2},
+		{WithinMinutes: 240, Multiplier: 1.25},
+	}
+
+	tests := []struct {
+		name    string
+		bonuses []*FastReviewBonus
+		elapsed time.Duration
+		want    float64
+	}{
+		{
+			name:    "no bonuses",
+			bonuses: nil,
+			elapsed: time.Minute,
+			want:    1,
+		},
+		{
+			name:    "highest matching window applies",
+			bonuses: bonuses,
+			elapsed: time.Minute * 10,
+			want:    2,
+		},
+		{
+			name:    "window end is inclusive",
+			bonuses: bonuses,
+			elapsed: time.Minute * 15,
+			want:    2,
+		},
+		{
+			name:    "just outside the shortest window",
+			bonuses: bonuses,
+			elapsed: time.Minute*15 + time.Second,
+			want:    1.5,
+		},
+		{
+			name:    "only the longest window",
+			bonuses: bonuses,
+			elapsed: time.Hour * 3,
+			want:    1.25,
+		},
+		{
+			name:    "outside every window",
+			bonuses: bonuses,
+			elapsed: time.Hour*4 + time.Second,
+			want:    1,
+		},
+		{
+			name:    "multipliers below one are ignored",
+			bonuses: []*FastReviewBonus{{WithinMinutes: 60, Multiplier: 0.5}},
+			elapsed: time.Minute,
+			want:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FastReviewMultiplier(tt.bonuses, tt.elapsed); got != tt.want {
+				t.Errorf("FastReviewMultiplier(%v) = %v, want %v", tt.elapsed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoostLimitMessage(t *testing.T) {
+	limits := &ChannelConfig{MinBounty: 5, MaxBounty: 20, MaxBoostPerUser: 10, DailySpendCap: 15}
+
+	tests := []struct {
+		name          string
+		channelConfig *ChannelConfig
+		spentToday    int
+		currentBounty int
+		contributed   int
+		boostAmount   int
+		want          string
+	}{
+		{
+			name:          "no limits",
+			channelConfig: &ChannelConfig{},
+			spentToday:    100,
+			currentBounty: 100,
+			contributed:   100,
+			boostAmount:   1,
+			want:          "",
+		},
+		{
+			name:          "within every limit",
+			channelConfig: limits,
+			spentToday:    5,
+			currentBounty: 5,
+			contributed:   5,
+			boostAmount:   5,
+			want:          "",
+		},
+		{
+			name:          "reaches every limit exactly",
+			channelConfig: limits,
+			spentToday:    10,
+			currentBounty: 15,
+			contributed:   5,
+			boostAmount:   5,
+			want:          "",
+		},
+		{
+			name:          "over the daily spend cap",
+			channelConfig: limits,
+			spentToday:    12,
+			currentBounty: 5,
+			boostAmount:   5,
+			want:          "You can put up to 15 towards bounties in this channel each day and have 3 left today.",
+		},
+		{
+			name:          "spend cap lowered below what's been spent",
+			channelConfig: limits,
+			spentToday:    18,
+			currentBounty: 5,
+			boostAmount:   1,
+			want:          "You can put up to 15 towards bounties in this channel each day and have 0 left today.",
+		},
+		{
+			name:          "below the minimum",
+			channelConfig: limits,
+			boostAmount:   3,
+			want:          "Bounties in this channel need to be at least 5, this one would only be 3.",
+		},
+		{
+			name:          "over the maximum",
+			channelConfig: limits,
+			currentBounty: 18,
+			boostAmount:   3,
+			want:          "Bounties in this channel can be at most 20, this one is already 18.",
+		},
+		{
+			name:          "over the per user limit",
+			channelConfig: limits,
+			currentBounty: 10,
+			contributed:   8,
+			boostAmount:   3,
+			want:          "You can contribute up to 10 to each bounty in this channel and have already contributed 8 to this one.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := boostLimitMessage(tt.channelConfig, tt.spentToday, tt.currentBounty, tt.contributed, tt.boostAmount)
+			if got != tt.want {
+				t.Errorf("boostLimitMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTotalContributions(t *testing.T) {
+	tests := []struct {
+		name          string
+		contributions []*types.MessageBountyContribution
+		want          []*types.BountyContributor
+	}{
+		{
+			name:          "no contributions",
+			contributions: nil,
+			want:          nil,
+		},
+		{
+			name: "contributions from the same user are added up",
+			contributions: []*types.MessageBountyContribution{
+				{Id: 3, UserId: "U1", Amount: 2},
+				{Id: 2, UserId: "U2", Amount: 3},
+				{Id: 1, UserId: "U1", Amount: 2},
+			},
+			want: []*types.BountyContributor{
+				{UserId: "U1", Amount: 4},
+				{UserId: "U2", Amount: 3},
+			},
+		},
+		{
+			name: "ties go to whoever contributed first",
+			contributions: []*types.MessageBountyContribution{
+				{Id: 4, UserId: "U2", Amount: 1},
+				{Id: 3, UserId: "U1", Amount: 1},
+				{Id: 2, UserId: "U2", Amount: 1},
+				{Id: 1, UserId: "U3", Amount: 1},
+				{Id: 5, UserId: "U3", Amount: 1},
+			},
+			want: []*types.BountyContributor{
+				{UserId: "U3", Amount: 2},
+				{UserId: "U2", Amount: 2},
+				{UserId: "U1", Amount: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := totalContributions(tt.contributions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("totalContributions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
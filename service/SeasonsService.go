@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/buzzology/slack_bot/db"
+	"github.com/buzzology/slack_bot/service/api"
+	"github.com/pkg/errors"
+)
+
+// seasonDateLayout is the format of the season start and end dates in the config.
+const seasonDateLayout = "2006-01-02"
+
+type ISeasonsService interface {
+	ActiveSeason(now time.Time) (*Season, error)
+	Standings(ctx context.Context, channelId string, season string) (*api.SlackBlocks, error)
+	Summary(ctx context.Context, channelId string) (*api.SlackBlocks, error)
+	Results(ctx context.Context, channelId string, season string) (*api.SlackBlocks, error)
+}
+
+// SeasonsService tracks the configured seasons and displays their standings.
+type SeasonsService struct {
+	config              *Config
+	botStateRepo        db.BotStateRepo
+	channelAccountsRepo db.ChannelAccountsRepo
+	seasonResultsRepo   db.SeasonResultsRepo
+}
+
+func NewSeasonsService(
+	config *Config,
+	botStateRepo db.BotStateRepo,
+	channelAccountsRepo db.ChannelAccountsRepo,
+	seasonResultsRepo db.SeasonResultsRepo,
+) *SeasonsService {
+	return &SeasonsService{
+		config:              config,
+		botStateRepo:        botStateRepo,
+		channelAccountsRepo: channelAccountsRepo,
+		seasonResultsRepo:   seasonResultsRepo,
+	}
+}
+
+// ActiveSeason returns the configured season that includes the time, nil if there isn't one. The first match is used
+// when seasons overlap.
+func (s *SeasonsService) ActiveSeason(now time.Time) (*Season, error) {
+	for _, season := range s.config.Seasons {
+		start, err := time.ParseInLocation(seasonDateLayout, season.Start, now.Location())
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid start date for season: %v", season.Name)
+		}
+
+		end, err := time.ParseInLocation(seasonDateLayout, season.End, now.Location())
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid end date for season: %v", season.Name)
+		}
+
+		// The end date is inclusive.
+		if !now.Before(start) && now.Before(end.AddDate(0, 0, 1)) {
+			return season, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Standings generates a leaderboard for the earnings of the season that is currently being tracked.
+func (s *SeasonsService) Standings(ctx context.Context, channelId string, season string) (*api.SlackBlocks, error) {
+	channelAccounts, err := s.channelAccountsRepo.LeadersThisSeason(api.TeamIdFromContext(ctx), s.config.AccountChannelId(channelId), 100, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderFields := api.SlackFieldsBlock{
+		Type: "section",
+	}
+
+	// Add each of the leaders as a block.
+	for index, channelAccount := range channelAccounts {
+		leaderFields.Fields = append(
+			leaderFields.Fields,
+			generateLeaderField(
+				channelAccount.UserId,
+				index+1,
+				channelAccount.EarnedThisSeason,
+			)...)
+	}
+
+	return GenerateLeaderboard(fmt.Sprintf("%v Standings", season), channelAccounts, leaderFields), nil
+}
+
+// Summary shows the standings of the current season along with the names of the seasons that have been archived.
+func (s *SeasonsService) Summary(ctx context.Context, channelId string) (*api.SlackBlocks, error) {
+	botState, err := s.botStateRepo.Get()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the current season")
+	}
+
+	var blocks []interface{}
+	if botState != nil && botState.Season != "" {
+		standings, err := s.Standings(ctx, channelId, botState.Season)
+		if err != nil {
+			return nil, err
+		}
+
+		blocks = append(blocks, standings.Blocks.([]interface{})...)
+	} else {
+		blocks = append(blocks, newSeasonTextBlock("There isn't a season running at the moment."))
+	}
+
+	seasons, err := s.seasonResultsRepo.Seasons(api.TeamIdFromContext(ctx), s.config.AccountChannelId(channelId))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seasons) > 0 {
+		blocks = append(
+			blocks,
+			newSeasonTextBlock(
+				fmt.Sprintf("Past seasons: %v. Use `/bountyseason <name>` to see their results.", strings.Join(seasons, ", ")),
+			),
+		)
+	}
+
+	return &api.SlackBlocks{Blocks: blocks}, nil
+}
+
+// Results generates a leaderboard from the final standings that were archived at the end of the season.
+func (s *SeasonsService) Results(ctx context.Context, channelId string, season string) (*api.SlackBlocks, error) {
+	results, err := s.seasonResultsRepo.List(api.TeamIdFromContext(ctx), s.config.AccountChannelId(channelId), season, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return &api.SlackBlocks{
+			Blocks: []interface{}{
+				newSeasonTextBlock(fmt.Sprintf("There are no results for a season named \"%v\" in this channel.", season)),
+			},
+		}, nil
+	}
+
+	leaderFields := api.SlackFieldsBlock{
+		Type: "section",
+	}
+
+	for index, result := range results {
+		leaderFields.Fields = append(
+			leaderFields.Fields,
+			generateLeaderField(
+				result.UserId,
+				index+1,
+				result.Earned,
+			)...)
+	}
+
+	return &api.SlackBlocks{
+		Blocks: []interface{}{
+			&api.SlackBlock{
+				Type: "header",
+				Text: api.SlackBlock{
+					Type: "plain_text",
+					Text: fmt.Sprintf("%v Final Standings", season),
+				},
+			},
+			&api.SlackBlockRawType{
+				Type: "divider",
+			},
+			leaderFields,
+		},
+	}, nil
+}
+
+// newSeasonTextBlock returns a section containing the markdown text.
+func newSeasonTextBlock(text string) *api.SlackBlock {
+	return &api.SlackBlock{
+		Type: "section",
+		Text: &api.SlackBlockText{
+			Type: "mrkdwn",
+			Text: text,
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActiveSeason(t *testing.T) {
+	s := &SeasonsService{
+		config: &Config{
+			Seasons: []*Season{
+				{Name: "Q1", Start: "2026-01-01", End: "2026-03-31"},
+				{Name: "Spring", Start: "2026-03-01", End: "2026-05-31"},
+				{Name: "Q3", Start: "2026-07-01", End: "2026-09-30"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want string
+	}{
+		{
+			name: "before every season",
+			now:  time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC),
+			want: "",
+		},
+		{
+			name: "start date is inclusive",
+			now:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: "Q1",
+		},
+		{
+			name: "overlapping seasons use the first entry",
+			now:  time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC),
+			want: "Q1",
+		},
+		{
+			name: "end date is inclusive",
+			now:  time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC),
+			want: "Q1",
+		},
+		{
+			name: "overlapping season continues once the first ends",
+			now:  time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+			want: "Spring",
+		},
+		{
+			name: "between seasons",
+			now:  time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC),
+			want: "",
+		},
+		{
+			name: "dates are in the location of the time",
+			now:  time.Date(2026, 7, 1, 0, 30, 0, 0, time.FixedZone("AEST", 10*60*60)),
+			want: "Q3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			season, err := s.ActiveSeason(tt.now)
+			if err != nil {
+				t.Fatalf("ActiveSeason(%v) returned an error: %v", tt.now, err)
+			}
+
+			var got string
+			if season != nil {
+				got = season.Name
+			}
+
+			if got != tt.want {
+				t.Errorf("ActiveSeason(%v) = %q, want %q", tt.now, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/buzzology/slack_bot/db"
+	"github.com/buzzology/slack_bot/service/api"
+	"github.com/buzzology/slack_bot/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrSelfTip is returned when a user attempts to tip themselves.
+	ErrSelfTip = errors.New("users can't tip themselves")
+	// ErrTipLimitExceeded is returned when a tip would take the user over the daily tip limit.
+	ErrTipLimitExceeded = errors.New("daily tip limit exceeded")
+)
+
+type ITipsService interface {
+	Tip(ctx context.Context, channelId string, from *types.ChannelAccount, to *types.ChannelAccount, amount int, note string) (*types.Tip, error)
+	RemainingTipLimit(ctx context.Context, channelId string, from *types.ChannelAccount) (int, error)
+}
+
+// TipsService moves points between users outside of bounties.
+type TipsService struct {
+	config              *Config
+	tipsRepo            db.TipsRepo
+	channelAccountsRepo db.ChannelAccountsRepo
+	botStateRepo        db.BotStateRepo
+	unitOfWork          *db.UnitOfWork
+	log                 *logrus.Logger
+}
+
+func NewTipsService(
+	config *Config,
+	tipsRepo db.TipsRepo,
+	channelAccountsRepo db.ChannelAccountsRepo,
+	botStateRepo db.BotStateRepo,
+	unitOfWork *db.UnitOfWork,
+	log *logrus.Logger,
+) *TipsService {
+	return &TipsService{
+		config:              config,
+		tipsRepo:            tipsRepo,
+		channelAccountsRepo: channelAccountsRepo,
+		botStateRepo:        botStateRepo,
+		unitOfWork:          unitOfWork,
+		log:                 log,
+	}
+}
+
+// Tip moves points from one user's channel account to another's. db.ErrInsufficientFunds is returned if the sender
+// can't afford it and ErrTipLimitExceeded if it would take them over the daily tip limit. The channel is where the tip
+// was sent, the accounts may be workspace wallets in which case the tip is recorded (and limited) for the workspace.
+func (s *TipsService) Tip(
+	ctx context.Context,
+	channelId string,
+	from *types.ChannelAccount,
+	to *types.ChannelAccount,
+	amount int,
+	note string,
+) (*types.Tip, error) {
+	if from.UserId == to.UserId {
+		return nil, ErrSelfTip
+	}
+
+	if from.ChannelId != to.ChannelId {
+		return nil, errors.Errorf("tips can only be sent between accounts in the same channel: %v, %v", from.ChannelId, to.ChannelId)
+	}
+
+	if amount <= 0 {
+		return nil, errors.Errorf("tips must be greater than zero: %v", amount)
+	}
+
+	tip := &types.Tip{
+		TeamId:     api.TeamIdFromContext(ctx),
+		ChannelId:  s.config.AccountChannelId(channelId),
+		FromUserId: from.UserId,
+		ToUserId:   to.UserId,
+		Amount:     amount,
+		Note:       note,
+	}
+
+	if err := s.unitOfWork.Run(func(tx *sql.Tx) error {
+		// The transfer locks the sender's account, so their tips are totalled one at a time and concurrent tips can't
+		// each fit under the limit.
+		if err := s.channelAccountsRepo.WithTx(tx).Transfer(from.Id, to.Id, amount); err != nil {
+			return err
+		}
+
+		remaining, err := s.remainingTipLimit(ctx, s.tipsRepo.WithTx(tx), channelId, from)
+		if err != nil {
+			return err
+		}
+
+		if remaining >= 0 && amount > remaining {
+			return ErrTipLimitExceeded
+		}
+
+		return s.tipsRepo.WithTx(tx).Create(tip)
+	}); err != nil {
+		return nil, err
+	}
+
+	return tip, nil
+}
+
+// RemainingTipLimit returns how much more the user can tip in the channel (or workspace) today, -1 if there is no limit.
+func (s *TipsService) RemainingTipLimit(ctx context.Context, channelId string, from *types.ChannelAccount) (int, error) {
+	return s.remainingTipLimit(ctx, s.tipsRepo, channelId, from)
+}
+
+// remainingTipLimit returns how much more the user can tip in the channel today using the tips repo, which may be bound
+// to a transaction.
+func (s *TipsService) remainingTipLimit(ctx context.Context, tipsRepo db.TipsRepo, channelId string, from *types.ChannelAccount) (int, error) {
+	if s.config.DailyTipLimit <= 0 {
+		return -1, nil
+	}
+
+	// Tips are limited per bot day so that the limit resets alongside the daily leaderboard.
+	botState, err := s.botStateRepo.Get()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to retrieve bot state to check the tip limit")
+	}
+
+	since := time.Now().Add(-time.Hour * 24)
+	if botState != nil {
+		since = botState.DayTickover.AsTime().Add(-time.Hour * 24)
+	}
+
+	sent, err := tipsRepo.SumSentSince(api.TeamIdFromContext(ctx), s.config.AccountChannelId(channelId), from.UserId, since)
+	if err != nil {
+		return 0, err
+	}
+
+	if sent >= s.config.DailyTipLimit {
+		return 0, nil
+	}
+
+	return s.config.DailyTipLimit - sent, nil
+}
@@ -10,6 +10,7 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -23,20 +24,48 @@ type SlackApi interface {
 	OpenView(ctx context.Context, request *SlackViewsOpenRequest) (*SlackOpenViewResponse, error)
 }
 
+// TokenResolver looks up the bot token for a workspace, an empty token is returned if it hasn't been installed.
+type TokenResolver interface {
+	BotToken(teamId string) (string, error)
+}
+
 type SlackApiClient struct {
-	config *ApiConfig
-	log    *logrus.Logger
+	config        *ApiConfig
+	log           *logrus.Logger
+	tokenResolver TokenResolver
 }
 
 // NewSlackApiClient return a new Slack API client.
 func NewSlackApiClient(
 	config *ApiConfig,
 	log *logrus.Logger,
+	tokenResolver TokenResolver,
 ) *SlackApiClient {
 	return &SlackApiClient{
-		config: config,
-		log:    log,
+		config:        config,
+		log:           log,
+		tokenResolver: tokenResolver,
+	}
+}
+
+// botToken resolves the token for the workspace in the context, falling back to the configured token.
+func (c *SlackApiClient) botToken(ctx context.Context) (string, error) {
+	teamId := TeamIdFromContext(ctx)
+	if teamId == "" || c.tokenResolver == nil {
+		return c.config.Token, nil
+	}
+
+	token, err := c.tokenResolver.BotToken(teamId)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve bot token for team: %v", teamId)
+	}
+
+	// Workspaces that haven't been installed via oauth use the configured token.
+	if token == "" {
+		return c.config.Token, nil
 	}
+
+	return token, nil
 }
 
 // GetSlackMessage retrieves a specific slack message via the slack API. Docs: https://api.slack.com/messaging/retrieving#individual_messages
@@ -70,7 +99,12 @@ func (c *SlackApiClient) GetSlackMessage(
 		return nil, errors.Wrap(err, "error building SlackConversationHistory request")
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	token, err := c.botToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
 	httpClient := &http.Client{
@@ -169,7 +203,12 @@ func (c *SlackApiClient) SendMessage(
 		return nil, errors.Wrap(err, "error building SlackPostMessageRequest request")
 	}
 
-	httpReq.Header.Set("Authorization", "Bearer "+c.config.Token)
+	token, err := c.botToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+token)
 	httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
 
 	dump, err := httputil.DumpRequestOut(httpReq, true)
@@ -234,7 +273,12 @@ func (c *SlackApiClient) DeleteSlackMessage(
 		return nil, errors.Wrap(err, "error building SlackDeleteMessage request")
 	}
 
-	httpReq.Header.Set("Authorization", "Bearer "+c.config.Token)
+	token, err := c.botToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+token)
 	httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
 
 	// TODO: Remove this when testing is finished.
@@ -300,7 +344,12 @@ func (c *SlackApiClient) OpenView(
 		return nil, errors.Wrap(err, "error building request to OpenView request")
 	}
 
-	httpReq.Header.Set("Authorization", "Bearer "+c.config.Token)
+	token, err := c.botToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+token)
 	httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
 
 	httpClient := &http.Client{
@@ -333,3 +382,263 @@ func (c *SlackApiClient) OpenView(
 
 	return &slackApiResponse, nil
 }
+
+// OpenConnection requests a websocket url for socket mode using the app-level token. Docs: https://api.slack.com/methods/apps.connections.open
+func (c *SlackApiClient) OpenConnection(
+	ctx context.Context,
+) (*SlackConnectionsOpenResponse, error) {
+	var (
+		err     error
+		httpReq *http.Request
+	)
+
+	requestUrl, err := url.Parse(c.config.Endpoint + "/apps.connections.open")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create connections open url")
+	}
+
+	if httpReq, err = http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		requestUrl.String(),
+		nil,
+	); err != nil {
+		return nil, errors.Wrap(err, "error building OpenConnection request")
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.config.AppToken)
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	httpClient := &http.Client{
+		Timeout: time.Second * 10,
+	}
+
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.handleHTTPResponse(resp); err != nil {
+		return nil, errors.Wrap(err, "unsuccessful response to OpenConnection request")
+	}
+
+	defer resp.Body.Close()
+
+	var slackApiResponse SlackConnectionsOpenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&slackApiResponse); err != nil {
+		return nil, errors.Wrap(err, "error decoding OpenConnection response")
+	}
+
+	if !slackApiResponse.Ok {
+		c.log.WithFields(logrus.Fields{
+			"error": slackApiResponse.Error,
+		}).Error("slack OpenConnection api message failed")
+		return nil, errors.Errorf("failed to open socket mode connection: %v", slackApiResponse.Error)
+	}
+
+	return &slackApiResponse, nil
+}
+
+// OAuthV2Access exchanges an oauth code for a workspace's bot token. Docs: https://api.slack.com/methods/oauth.v2.access
+func (c *SlackApiClient) OAuthV2Access(
+	ctx context.Context,
+	code string,
+) (*SlackOAuthAccessResponse, error) {
+	var (
+		err     error
+		httpReq *http.Request
+	)
+
+	requestUrl, err := url.Parse(c.config.Endpoint + "/oauth.v2.access")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create oauth access url")
+	}
+
+	form := url.Values{}
+	form.Set("code", code)
+	form.Set("redirect_uri", c.config.RedirectUrl)
+
+	if httpReq, err = http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		requestUrl.String(),
+		strings.NewReader(form.Encode()),
+	); err != nil {
+		return nil, errors.Wrap(err, "error building OAuthV2Access request")
+	}
+
+	httpReq.SetBasicAuth(c.config.ClientId, c.config.ClientSecret)
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	httpClient := &http.Client{
+		Timeout: time.Second * 10,
+	}
+
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.handleHTTPResponse(resp); err != nil {
+		return nil, errors.Wrap(err, "unsuccessful response to OAuthV2Access request")
+	}
+
+	defer resp.Body.Close()
+
+	var slackApiResponse SlackOAuthAccessResponse
+	if err := json.NewDecoder(resp.Body).Decode(&slackApiResponse); err != nil {
+		return nil, errors.Wrap(err, "error decoding OAuthV2Access response")
+	}
+
+	if !slackApiResponse.Ok {
+		c.log.WithFields(logrus.Fields{
+			"error": slackApiResponse.Error,
+		}).Error("slack OAuthV2Access api message failed")
+		return nil, errors.Errorf("failed to exchange oauth code: %v", slackApiResponse.Error)
+	}
+
+	return &slackApiResponse, nil
+}
+
+// AuthorizeUrl returns the url users visit to install the bot to their workspace.
+func (c *SlackApiClient) AuthorizeUrl(state string) string {
+	q := url.Values{}
+	q.Set("client_id", c.config.ClientId)
+	q.Set("scope", strings.Join(c.config.Scopes, ","))
+	q.Set("redirect_uri", c.config.RedirectUrl)
+	q.Set("state", state)
+
+	return "https://slack.com/oauth/v2/authorize?" + q.Encode()
+}
+
+// GetUserInfo retrieves a user via the slack API. Docs: https://api.slack.com/methods/users.info
+func (c *SlackApiClient) GetUserInfo(
+	ctx context.Context,
+	userId string,
+) (*SlackUser, error) {
+	var (
+		err error
+		req *http.Request
+	)
+
+	requestUrl, err := url.Parse(c.config.Endpoint + "/users.info")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create users info url")
+	}
+
+	q := requestUrl.Query()
+	q.Set("user", userId)
+
+	requestUrl.RawQuery = q.Encode()
+	if req, err = http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		requestUrl.String(),
+		nil,
+	); err != nil {
+		return nil, errors.Wrap(err, "error building SlackUsersInfo request")
+	}
+
+	token, err := c.botToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	httpClient := &http.Client{
+		Timeout: time.Second * 10,
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.handleHTTPResponse(resp); err != nil {
+		return nil, errors.Wrap(err, "unsuccessful response to SlackUsersInfo request")
+	}
+
+	defer resp.Body.Close()
+
+	var slackUsersInfoResponse SlackUsersInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&slackUsersInfoResponse); err != nil {
+		return nil, errors.Wrap(err, "error decoding SlackUsersInfo response")
+	}
+
+	if !slackUsersInfoResponse.Ok || slackUsersInfoResponse.User == nil {
+		c.log.WithFields(logrus.Fields{
+			"error": slackUsersInfoResponse.Error,
+		}).Error("slack users info api message failed")
+		return nil, errors.Errorf("failed to retrieve slack user: %v", slackUsersInfoResponse.Error)
+	}
+
+	return slackUsersInfoResponse.User, nil
+}
+
+// GetConversationInfo retrieves a channel via the slack API. Docs: https://api.slack.com/methods/conversations.info
+func (c *SlackApiClient) GetConversationInfo(
+	ctx context.Context,
+	channelId string,
+) (*SlackChannel, error) {
+	var (
+		err error
+		req *http.Request
+	)
+
+	requestUrl, err := url.Parse(c.config.Endpoint + "/conversations.info")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create conversations info url")
+	}
+
+	q := requestUrl.Query()
+	q.Set("channel", channelId)
+
+	requestUrl.RawQuery = q.Encode()
+	if req, err = http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		requestUrl.String(),
+		nil,
+	); err != nil {
+		return nil, errors.Wrap(err, "error building SlackConversationsInfo request")
+	}
+
+	token, err := c.botToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	httpClient := &http.Client{
+		Timeout: time.Second * 10,
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.handleHTTPResponse(resp); err != nil {
+		return nil, errors.Wrap(err, "unsuccessful response to SlackConversationsInfo request")
+	}
+
+	defer resp.Body.Close()
+
+	var slackConversationsInfoResponse SlackConversationsInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&slackConversationsInfoResponse); err != nil {
+		return nil, errors.Wrap(err, "error decoding SlackConversationsInfo response")
+	}
+
+	if !slackConversationsInfoResponse.Ok || slackConversationsInfoResponse.Channel == nil {
+		c.log.WithFields(logrus.Fields{
+			"error": slackConversationsInfoResponse.Error,
+		}).Error("slack conversations info api message failed")
+		return nil, errors.Errorf("failed to retrieve slack channel: %v", slackConversationsInfoResponse.Error)
+	}
+
+	return slackConversationsInfoResponse.Channel, nil
+}
@@ -3,4 +3,12 @@ package api
 type ApiConfig struct {
 	Endpoint string
 	Token    string
+	// AppToken is the app-level token (xapp-) used to open socket mode connections.
+	AppToken string
+	// ClientId, ClientSecret and RedirectUrl are used for the oauth install flow when serving multiple workspaces.
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	// Scopes are the bot scopes requested when installing to a workspace.
+	Scopes []string
 }
@@ -0,0 +1,19 @@
+package api
+
+import "context"
+
+type contextKey string
+
+// teamIdContextKey holds the workspace that the current event, command or interaction was received from.
+const teamIdContextKey contextKey = "team_id"
+
+// ContextWithTeamId returns a context that api requests will use to resolve the workspace's bot token.
+func ContextWithTeamId(ctx context.Context, teamId string) context.Context {
+	return context.WithValue(ctx, teamIdContextKey, teamId)
+}
+
+// TeamIdFromContext returns the workspace the context belongs to, an empty string is returned if there isn't one.
+func TeamIdFromContext(ctx context.Context) string {
+	teamId, _ := ctx.Value(teamIdContextKey).(string)
+	return teamId
+}
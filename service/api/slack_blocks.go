@@ -12,6 +12,8 @@ type SlackBlock struct {
 	Accessory *SlackBlockAccessory `json:"accessory,omitempty"`
 	Element   interface{}          `json:"element,omitempty"`
 	Label     *SlackBlockLabel     `json:"label,omitempty"`
+	Hint      interface{}          `json:"hint,omitempty"`
+	Optional  bool                 `json:"optional,omitempty"`
 }
 
 type SlackBlockLabel struct {
@@ -30,10 +32,11 @@ type SlackBlockRawType struct {
 }
 
 type SlackBlockAccessory struct {
-	ActionId    string      `json:"action_id"`
-	Type        string      `json:"type"`
-	Placeholder interface{} `json:"placeholder,omitempty"`
-	Text        interface{} `json:"text,omitempty"`
+	ActionId     string      `json:"action_id"`
+	Type         string      `json:"type"`
+	Placeholder  interface{} `json:"placeholder,omitempty"`
+	Text         interface{} `json:"text,omitempty"`
+	InitialValue string      `json:"initial_value,omitempty"`
 }
 
 type SlackBlockSubmit struct {
@@ -3,4 +3,6 @@ package api
 type SlackChannel struct {
 	Id   string `json:"id"`
 	Name string `json:"name"`
+	// Creator is the user that created the channel, only included by conversations.info.
+	Creator string `json:"creator,omitempty"`
 }
@@ -0,0 +1,8 @@
+package api
+
+// SlackConnectionsOpenResponse is a response to requesting a socket mode url.
+type SlackConnectionsOpenResponse struct {
+	Ok    bool   `json:"ok"`
+	Url   string `json:"url"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,7 @@
+package api
+
+type SlackConversationsInfoResponse struct {
+	Ok      bool          `json:"ok"`
+	Channel *SlackChannel `json:"channel"`
+	Error   string        `json:"error"`
+}
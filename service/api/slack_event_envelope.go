@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// SlackEventEnvelope is the outer wrapper slack sends for url verification and every event_callback.
+// Docs: https://api.slack.com/apis/connections/events-api#the-events-api__receiving-events__callback-field-overview
+type SlackEventEnvelope struct {
+	Token     string `json:"token"`
+	TeamId    string `json:"team_id"`
+	ApiAppId  string `json:"api_app_id"`
+	Type      string `json:"type"`
+	EventId   string `json:"event_id"`
+	EventTime int64  `json:"event_time"`
+	// Challenge is only provided with url_verification requests.
+	Challenge string `json:"challenge"`
+	// Event is left raw so that it can be decoded by the handler registered for its type.
+	Event json.RawMessage `json:"event"`
+}
+
+// SlackEventHeader holds the fields common to every inner event, used to determine how it should be decoded.
+type SlackEventHeader struct {
+	Type    string `json:"type"`
+	Subtype string `json:"subtype"`
+}
+
+// Header decodes the inner event's type and subtype.
+func (e *SlackEventEnvelope) Header() (*SlackEventHeader, error) {
+	if len(e.Event) == 0 {
+		return nil, errors.New("event envelope does not contain an event")
+	}
+
+	header := &SlackEventHeader{}
+	if err := json.Unmarshal(e.Event, header); err != nil {
+		return nil, errors.Wrap(err, "failed to decode event header")
+	}
+
+	if header.Type == "" {
+		return nil, errors.New("event does not have a type")
+	}
+
+	return header, nil
+}
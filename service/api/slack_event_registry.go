@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnhandledEvent is returned when no handler has been registered for an event's type.
+var ErrUnhandledEvent = errors.New("no handler registered for event")
+
+// SlackEventDecoder converts the envelope into the typed event expected by the handler.
+type SlackEventDecoder func(envelope *SlackEventEnvelope) (interface{}, error)
+
+// SlackEventHandlerFunc processes a decoded event.
+type SlackEventHandlerFunc func(ctx context.Context, event interface{}) error
+
+type slackEventRegistration struct {
+	decode SlackEventDecoder
+	handle SlackEventHandlerFunc
+}
+
+// SlackEventRegistry routes events to the handler registered for their type (and optionally subtype).
+type SlackEventRegistry struct {
+	registrations map[string]*slackEventRegistration
+}
+
+// NewSlackEventRegistry returns an empty event registry.
+func NewSlackEventRegistry() *SlackEventRegistry {
+	return &SlackEventRegistry{
+		registrations: map[string]*slackEventRegistration{},
+	}
+}
+
+// Register adds a handler for an event type. When a subtype is provided the handler is only used for that subtype,
+// otherwise it is used for any subtype that doesn't have its own handler.
+func (r *SlackEventRegistry) Register(
+	eventType string,
+	subtype string,
+	decode SlackEventDecoder,
+	handle SlackEventHandlerFunc,
+) {
+	r.registrations[registryKey(eventType, subtype)] = &slackEventRegistration{
+		decode: decode,
+		handle: handle,
+	}
+}
+
+// Dispatch decodes the event and passes it to its handler. ErrUnhandledEvent is returned for unregistered types.
+func (r *SlackEventRegistry) Dispatch(ctx context.Context, envelope *SlackEventEnvelope) error {
+	header, err := envelope.Header()
+	if err != nil {
+		return errors.Wrapf(err, "malformed event: %v", envelope.EventId)
+	}
+
+	// Prefer a handler for the specific subtype before falling back to the type's handler.
+	registration, ok := r.registrations[registryKey(header.Type, header.Subtype)]
+	if !ok {
+		registration, ok = r.registrations[registryKey(header.Type, "")]
+	}
+
+	if !ok {
+		return errors.Wrapf(ErrUnhandledEvent, "%v", registryKey(header.Type, header.Subtype))
+	}
+
+	event, err := registration.decode(envelope)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode %v event: %v", header.Type, envelope.EventId)
+	}
+
+	return registration.handle(ctx, event)
+}
+
+func registryKey(eventType string, subtype string) string {
+	if subtype == "" {
+		return eventType
+	}
+
+	return eventType + "." + subtype
+}
@@ -4,6 +4,7 @@ type SlackInteraction struct {
 	Type        string       `json:"type"`
 	Token       string       `json:"token"`
 	ActionTs    string       `json:"text"`
+	Team        SlackTeam    `json:"team"`
 	User        SlackUser    `json:"user"`
 	Channel     SlackChannel `json:"channel"`
 	Ts          string       `json:"ts"`
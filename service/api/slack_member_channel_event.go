@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// SlackMemberChannelEvent is sent when a user joins or leaves a channel the bot is a member of.
+type SlackMemberChannelEvent struct {
+	EventID string                        `json:"event_id"`
+	Type    string                        `json:"type"`
+	Event   *SlackMemberChannelEventEvent `json:"event"`
+	Token   string                        `json:"token"`
+	TeamID  string                        `json:"team_id"`
+}
+
+type SlackMemberChannelEventEvent struct {
+	// Type is either member_joined_channel or member_left_channel.
+	Type        string `json:"type"`
+	User        string `json:"user"`
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type"`
+	Team        string `json:"team"`
+}
+
+// DecodeSlackMemberChannelEvent is the registry decoder for member_joined_channel and member_left_channel events.
+func DecodeSlackMemberChannelEvent(envelope *SlackEventEnvelope) (interface{}, error) {
+	event := &SlackMemberChannelEventEvent{}
+	if err := json.Unmarshal(envelope.Event, event); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal member channel event")
+	}
+
+	return &SlackMemberChannelEvent{
+		EventID: envelope.EventId,
+		Type:    envelope.Type,
+		Event:   event,
+		Token:   envelope.Token,
+		TeamID:  envelope.TeamId,
+	}, nil
+}
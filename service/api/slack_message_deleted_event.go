@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// SlackMessageDeletedEvent is sent when a message in a channel the bot is a member of is deleted.
+type SlackMessageDeletedEvent struct {
+	EventID string                         `json:"event_id"`
+	Type    string                         `json:"type"`
+	Event   *SlackMessageDeletedEventEvent `json:"event"`
+	Token   string                         `json:"token"`
+	TeamID  string                         `json:"team_id"`
+}
+
+type SlackMessageDeletedEventEvent struct {
+	Type    string `json:"type"`
+	Subtype string `json:"subtype"`
+	Channel string `json:"channel"`
+	// DeletedTs is the ts of the message that was deleted.
+	DeletedTs string `json:"deleted_ts"`
+	EventTs   string `json:"event_ts"`
+	// PreviousMessage is the message as it was before it was deleted.
+	PreviousMessage *SlackMessage `json:"previous_message"`
+}
+
+// DecodeSlackMessageDeletedEvent is the registry decoder for message_deleted events.
+func DecodeSlackMessageDeletedEvent(envelope *SlackEventEnvelope) (interface{}, error) {
+	event := &SlackMessageDeletedEventEvent{}
+	if err := json.Unmarshal(envelope.Event, event); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal message_deleted event")
+	}
+
+	return &SlackMessageDeletedEvent{
+		EventID: envelope.EventId,
+		Type:    envelope.Type,
+		Event:   event,
+		Token:   envelope.Token,
+		TeamID:  envelope.TeamId,
+	}, nil
+}
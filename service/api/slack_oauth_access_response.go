@@ -0,0 +1,19 @@
+package api
+
+// SlackOAuthAccessResponse is a response to exchanging an oauth code for a bot token.
+// Docs: https://api.slack.com/methods/oauth.v2.access
+type SlackOAuthAccessResponse struct {
+	Ok          bool            `json:"ok"`
+	Error       string          `json:"error"`
+	AccessToken string          `json:"access_token"`
+	TokenType   string          `json:"token_type"`
+	Scope       string          `json:"scope"`
+	BotUserId   string          `json:"bot_user_id"`
+	AppId       string          `json:"app_id"`
+	Team        SlackTeam       `json:"team"`
+	AuthedUser  SlackAuthedUser `json:"authed_user"`
+}
+
+type SlackAuthedUser struct {
+	Id string `json:"id"`
+}
@@ -1,9 +1,31 @@
 package api
 
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
 type SlackReactionAddedEvent struct {
-	EventID string              `event_id:"event_id"`
+	EventID string              `json:"event_id"`
 	Type    string              `json:"type"`
 	Event   *SlackReactionEvent `json:"event"`
 	Token   string              `json:"token"`
 	TeamID  string              `json:"team_id"`
 }
+
+// DecodeSlackReactionAddedEvent is the registry decoder for reaction_added events.
+func DecodeSlackReactionAddedEvent(envelope *SlackEventEnvelope) (interface{}, error) {
+	event := &SlackReactionEvent{}
+	if err := json.Unmarshal(envelope.Event, event); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal reaction_added event")
+	}
+
+	return &SlackReactionAddedEvent{
+		EventID: envelope.EventId,
+		Type:    envelope.Type,
+		Event:   event,
+		Token:   envelope.Token,
+		TeamID:  envelope.TeamId,
+	}, nil
+}
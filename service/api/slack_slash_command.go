@@ -0,0 +1,14 @@
+package api
+
+// SlackSlashCommand is the payload slack sends when a slash command is used.
+// Docs: https://api.slack.com/interactivity/slash-commands#app_command_handling
+type SlackSlashCommand struct {
+	Command     string `json:"command"`
+	Text        string `json:"text"`
+	UserId      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	ChannelId   string `json:"channel_id"`
+	TeamId      string `json:"team_id"`
+	TriggerId   string `json:"trigger_id"`
+	ResponseUrl string `json:"response_url"`
+}
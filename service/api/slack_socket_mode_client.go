@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// socketModeReadTimeout is how long we'll go without hearing from slack (including pings) before reconnecting.
+	socketModeReadTimeout = 2 * time.Minute
+
+	socketModeWriteTimeout     = 10 * time.Second
+	socketModeMinReconnectWait = time.Second
+	socketModeMaxReconnectWait = 30 * time.Second
+)
+
+// SocketModeHandlerFunc processes an envelope. The returned payload is sent back with the acknowledgement, if an error
+// is returned the envelope is not acknowledged so that slack will redeliver it.
+type SocketModeHandlerFunc func(ctx context.Context, envelope *SlackSocketModeEnvelope) (interface{}, error)
+
+// SlackSocketModeClient receives events, slash commands and interactions over a websocket instead of public http endpoints.
+// Docs: https://api.slack.com/apis/connections/socket-implement
+type SlackSocketModeClient struct {
+	apiClient *SlackApiClient
+	log       *logrus.Logger
+	dialer    *websocket.Dialer
+}
+
+// NewSlackSocketModeClient returns a new socket mode client.
+func NewSlackSocketModeClient(
+	apiClient *SlackApiClient,
+	log *logrus.Logger,
+) *SlackSocketModeClient {
+	return &SlackSocketModeClient{
+		apiClient: apiClient,
+		log:       log,
+		dialer:    websocket.DefaultDialer,
+	}
+}
+
+// Run connects to slack and passes each envelope to the handler, reconnecting as required until the context is done.
+func (c *SlackSocketModeClient) Run(ctx context.Context, handler SocketModeHandlerFunc) error {
+	reconnectWait := socketModeMinReconnectWait
+
+	for {
+		connected, err := c.connect(ctx, handler)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// Back off if we're unable to establish a connection, slack will ask us to reconnect periodically so a
+		// successful connection resets the wait.
+		if connected {
+			reconnectWait = socketModeMinReconnectWait
+		}
+
+		if err != nil {
+			c.log.WithError(err).WithField("retry_in", reconnectWait).Error("Socket mode connection failed.")
+		} else {
+			c.log.Info("Socket mode connection closed, reconnecting.")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reconnectWait):
+		}
+
+		if !connected {
+			reconnectWait *= 2
+			if reconnectWait > socketModeMaxReconnectWait {
+				reconnectWait = socketModeMaxReconnectWait
+			}
+		}
+	}
+}
+
+// connect opens a single websocket connection and reads from it until it is closed. Returns true if slack said hello.
+func (c *SlackSocketModeClient) connect(ctx context.Context, handler SocketModeHandlerFunc) (bool, error) {
+	connection, err := c.apiClient.OpenConnection(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to retrieve a socket mode url")
+	}
+
+	conn, _, err := c.dialer.DialContext(ctx, connection.Url, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to dial socket mode url")
+	}
+
+	var (
+		connected bool
+		writeLock sync.Mutex
+		inFlight  sync.WaitGroup
+	)
+
+	// Wait for any envelopes that are still being handled before giving up the connection.
+	defer func() {
+		inFlight.Wait()
+		conn.Close()
+	}()
+
+	// Close the connection when we're asked to stop so that the read below returns.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			writeLock.Lock()
+			conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+				time.Now().Add(socketModeWriteTimeout),
+			)
+			writeLock.Unlock()
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	// Slack pings periodically, treat these as a sign the connection is still alive.
+	conn.SetReadDeadline(time.Now().Add(socketModeReadTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(socketModeReadTimeout))
+
+		writeLock.Lock()
+		defer writeLock.Unlock()
+
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(socketModeWriteTimeout))
+	})
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return connected, nil
+			}
+
+			return connected, errors.Wrap(err, "failed to read from socket mode connection")
+		}
+
+		conn.SetReadDeadline(time.Now().Add(socketModeReadTimeout))
+
+		envelope := &SlackSocketModeEnvelope{}
+		if err = json.Unmarshal(message, envelope); err != nil {
+			c.log.WithError(err).Errorf("Failed to unmarshal socket mode envelope: %v", string(message))
+			continue
+		}
+
+		switch envelope.Type {
+		case "hello":
+			connected = true
+			c.log.Info("Socket mode connection established.")
+		case "disconnect":
+			c.log.WithField("reason", envelope.Reason).Info("Slack requested a socket mode reconnect.")
+			return connected, nil
+		default:
+			// Handle each envelope separately so that a slow slash command doesn't hold up acknowledging events.
+			inFlight.Add(1)
+			go func() {
+				defer inFlight.Done()
+				c.handleEnvelope(ctx, conn, &writeLock, envelope, handler)
+			}()
+		}
+	}
+}
+
+// handleEnvelope passes the envelope to the handler and acknowledges it if it was successful.
+func (c *SlackSocketModeClient) handleEnvelope(
+	ctx context.Context,
+	conn *websocket.Conn,
+	writeLock *sync.Mutex,
+	envelope *SlackSocketModeEnvelope,
+	handler SocketModeHandlerFunc,
+) {
+	logger := c.log.WithFields(logrus.Fields{
+		"envelope_id": envelope.EnvelopeId,
+		"type":        envelope.Type,
+	})
+
+	payload, err := handler(ctx, envelope)
+	if err != nil {
+		logger.WithError(err).Error("Failed to handle socket mode envelope, it will not be acknowledged.")
+		return
+	}
+
+	ack := &SlackSocketModeAck{
+		EnvelopeId: envelope.EnvelopeId,
+	}
+
+	if envelope.AcceptsResponsePayload {
+		ack.Payload = payload
+	}
+
+	writeLock.Lock()
+	defer writeLock.Unlock()
+
+	conn.SetWriteDeadline(time.Now().Add(socketModeWriteTimeout))
+	if err = conn.WriteJSON(ack); err != nil {
+		logger.WithError(err).Error("Failed to acknowledge socket mode envelope.")
+	}
+}
@@ -0,0 +1,28 @@
+package api
+
+import "encoding/json"
+
+// SlackSocketModeEnvelope wraps every message received over a socket mode connection.
+// Docs: https://api.slack.com/apis/connections/socket-implement
+type SlackSocketModeEnvelope struct {
+	// EnvelopeId must be sent back to slack to acknowledge the envelope.
+	EnvelopeId string `json:"envelope_id"`
+	// Type is one of hello, disconnect, events_api, slash_commands or interactive.
+	Type string `json:"type"`
+	// Payload is the same body that would have been posted to the equivalent http endpoint.
+	Payload json.RawMessage `json:"payload"`
+	// AcceptsResponsePayload is set when a response can be included with the acknowledgement.
+	AcceptsResponsePayload bool `json:"accepts_response_payload"`
+	// RetryAttempt is the equivalent of the X-Slack-Retry-Num header.
+	RetryAttempt int `json:"retry_attempt"`
+	// RetryReason is the equivalent of the X-Slack-Retry-Reason header.
+	RetryReason string `json:"retry_reason"`
+	// Reason is provided with disconnect envelopes.
+	Reason string `json:"reason"`
+}
+
+// SlackSocketModeAck acknowledges an envelope and optionally responds to it (e.g. slash commands).
+type SlackSocketModeAck struct {
+	EnvelopeId string      `json:"envelope_id"`
+	Payload    interface{} `json:"payload,omitempty"`
+}
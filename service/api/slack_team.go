@@ -0,0 +1,7 @@
+package api
+
+type SlackTeam struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+}
@@ -4,4 +4,11 @@ type SlackUser struct {
 	Id       string `json:"id"`
 	Username string `json:"username"`
 	TeamId   string `json:"team_id"`
+	// Deleted is set when the user has been deactivated.
+	Deleted bool `json:"deleted"`
+	// IsBot is set for bot users, including apps.
+	IsBot bool `json:"is_bot"`
+	// IsAdmin and IsOwner are only included by users.info.
+	IsAdmin bool `json:"is_admin"`
+	IsOwner bool `json:"is_owner"`
 }
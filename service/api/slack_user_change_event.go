@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// SlackUserChangeEvent is sent when a user's profile changes, including when they're deactivated.
+type SlackUserChangeEvent struct {
+	EventID string                     `json:"event_id"`
+	Type    string                     `json:"type"`
+	Event   *SlackUserChangeEventEvent `json:"event"`
+	Token   string                     `json:"token"`
+	TeamID  string                     `json:"team_id"`
+}
+
+type SlackUserChangeEventEvent struct {
+	Type string     `json:"type"`
+	User *SlackUser `json:"user"`
+}
+
+// DecodeSlackUserChangeEvent is the registry decoder for user_change events.
+func DecodeSlackUserChangeEvent(envelope *SlackEventEnvelope) (interface{}, error) {
+	event := &SlackUserChangeEventEvent{}
+	if err := json.Unmarshal(envelope.Event, event); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal user_change event")
+	}
+
+	if event.User == nil {
+		return nil, errors.New("user_change event did not include a user")
+	}
+
+	return &SlackUserChangeEvent{
+		EventID: envelope.EventId,
+		Type:    envelope.Type,
+		Event:   event,
+		Token:   envelope.Token,
+		TeamID:  envelope.TeamId,
+	}, nil
+}
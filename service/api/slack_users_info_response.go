@@ -0,0 +1,7 @@
+package api
+
+type SlackUsersInfoResponse struct {
+	Ok    bool       `json:"ok"`
+	User  *SlackUser `json:"user"`
+	Error string     `json:"error"`
+}
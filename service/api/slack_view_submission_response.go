@@ -0,0 +1,8 @@
+package api
+
+// SlackViewSubmissionResponse is returned in response to a modal submission, e.g. to display validation errors. The
+// errors are keyed by block id: https://api.slack.com/surfaces/modals/using#displaying_errors
+type SlackViewSubmissionResponse struct {
+	ResponseAction string            `json:"response_action"`
+	Errors         map[string]string `json:"errors,omitempty"`
+}
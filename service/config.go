@@ -1,12 +1,18 @@
 package service
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/buzzology/slack_bot/service/api"
+	"github.com/buzzology/slack_bot/types"
 )
 
 type Config struct {
 	// ApiConfig is the config used for the slack api.
-	ApiConfig                 *api.ApiConfig
+	ApiConfig *api.ApiConfig
+	// SigningSecret is used to verify that inbound requests were sent by slack.
+	SigningSecret             string
 	BoostReactions            []*BoostReactionValue
 	ReleaseBountyReaction     string
 	TaskCompletedByMeReaction string
@@ -14,15 +20,79 @@ type Config struct {
 	DailyIncome               int
 	DbConnection              string
 	DocumentationUrl          string
+	// LegacyTeamId is the workspace that rows created before multiple workspaces were supported belong to, they're
+	// assigned to it on startup.
+	LegacyTeamId string
+	// DecayMode is how balances decay each day, either "flat", "percentage" or "tiered".
+	DecayMode string
+	// BalanceDecayPercentage is the percentage of each balance that decays each day in percentage mode.
+	BalanceDecayPercentage int
+	// DecayTiers are the percentages that decay each day in tiered mode, the highest tier a balance has reached applies.
+	DecayTiers []*types.DecayTier
+	// MaxBalance caps balances on the daily tickover, zero means no cap.
+	MaxBalance int
+	// ProcessedEventsRetentionHours is how long event ids are kept to detect redeliveries from slack.
+	ProcessedEventsRetentionHours int
+	// SocketModeEnabled receives events over a websocket instead of the public http endpoints (requires ApiConfig.AppToken).
+	SocketModeEnabled bool
+	// EventWorkers is the number of workers processing slack events.
+	EventWorkers int
+	// EventQueueSize is the number of events that can be waiting for a worker before slack is asked to retry.
+	EventQueueSize int
+	// DepartedOwnerBounties is what happens to the open bounties of a user that leaves, either "refund" or "handover".
+	DepartedOwnerBounties string
+	// TransactionLedgerEnabled records every balance change in account_transactions.
+	TransactionLedgerEnabled bool
+	// TransactionRetentionDays is how long ledger transactions are kept, zero keeps them forever.
+	TransactionRetentionDays int
+	// BountyExpiryDays is how long a bounty can stay open before it expires, zero disables expiry.
+	BountyExpiryDays int
+	// BountyExpiryWarningDays is how many days before a bounty expires that its owner is warned.
+	BountyExpiryWarningDays int
+	// ExpiredBounties is what happens to a bounty when it expires, either "award" or "refund".
+	ExpiredBounties string
+	// DailyTipLimit is the most a user can tip in a channel each day, zero means no limit.
+	DailyTipLimit int
+	// TipAnnouncements is where tips are announced, either "channel" or "dm" (to the recipient).
+	TipAnnouncements string
+	// AccountScope is whether users have an account in each channel or a single wallet for the workspace, either
+	// "channel" or "workspace".
+	AccountScope string
+	// FastReviewBonuses reward claims made soon after a bounty was created, the highest matching multiplier applies.
+	FastReviewBonuses []*FastReviewBonus
+	// FastReviewBonusDailyCap is the most bonus a user can be paid in a channel each day, zero means no cap.
+	FastReviewBonusDailyCap int
+	// Seasons are the named periods that standings are archived for at the end of, e.g. quarters.
+	Seasons []*Season
+	// SeasonBalanceResetPercentage is the percentage of each balance removed when a season starts, zero disables it.
+	SeasonBalanceResetPercentage int
+	// MinBounty is the smallest a bounty can be once it has been boosted, zero means no minimum.
+	MinBounty int
+	// MaxBounty is the largest a bounty can be boosted to, zero means no maximum.
+	MaxBounty int
+	// MaxBoostPerUser is the most each user can contribute to a single bounty, zero means no limit.
+	MaxBoostPerUser int
+	// DailySpendCap is the most each user can spend in a channel each day, zero means no cap.
+	DailySpendCap int
 }
 
 // NewConfig returns a new instance of config.
 func NewConfig() *Config {
 	return &Config{
-		DbConnection: "",
+		DbConnection:  "",
+		SigningSecret: "",
 		ApiConfig: &api.ApiConfig{
 			Endpoint: "https://slack.com/api",
 			Token:    "<enter-bot-token-here-or-use-toml-config>",
+			Scopes: []string{
+				"channels:history",
+				"channels:read",
+				"chat:write",
+				"chat:write.customize",
+				"commands",
+				"reactions:read",
+				"users:read",
+			},
 		},
 		BoostReactions: []*BoostReactionValue{
 			{Emote: "dollar", BoostValue: 1},
@@ -31,14 +101,132 @@ func NewConfig() *Config {
 			{Emote: "take_my_money", BoostValue: 4},
 			{Emote: "moneyparrot", BoostValue: 5},
 		},
-		ReleaseBountyReaction:     "medal",
-		TaskCompletedByMeReaction: "white_check_mark",
-		DailyDecay:                2,
-		DailyIncome:               1, // NOTE: This is also re-used as starting balance when creating a new account.
+		ReleaseBountyReaction:         "medal",
+		TaskCompletedByMeReaction:     "white_check_mark",
+		DailyDecay:                    2,
+		DailyIncome:                   1, // NOTE: This is also re-used as starting balance when creating a new account.
+		DecayMode:                     types.DecayModeFlat,
+		BalanceDecayPercentage:        0,
+		MaxBalance:                    0,
+		ProcessedEventsRetentionHours: 24,
+		EventWorkers:                  4,
+		EventQueueSize:                100,
+		DepartedOwnerBounties:         DepartedOwnerBountiesRefund,
+		TransactionLedgerEnabled:      false,
+		TransactionRetentionDays:      90,
+		BountyExpiryDays:              0,
+		BountyExpiryWarningDays:       2,
+		ExpiredBounties:               ExpiredBountiesAward,
+		DailyTipLimit:                 10,
+		TipAnnouncements:              TipAnnouncementsChannel,
+		AccountScope:                  AccountScopeChannel,
+		FastReviewBonusDailyCap:       10,
+		SeasonBalanceResetPercentage:  0,
+		MinBounty:                     0,
+		MaxBounty:                     0,
+		MaxBoostPerUser:               0,
+		DailySpendCap:                 0,
+	}
+}
+
+const (
+	// DepartedOwnerBountiesRefund cancels the bounty and refunds its contributors.
+	DepartedOwnerBountiesRefund = "refund"
+	// DepartedOwnerBountiesHandover passes the bounty to its largest contributor, it's refunded if there isn't one.
+	DepartedOwnerBountiesHandover = "handover"
+)
+
+const (
+	// ExpiredBountiesAward splits the bounty between its claimants, it's refunded if nobody has claimed it.
+	ExpiredBountiesAward = "award"
+	// ExpiredBountiesRefund cancels the bounty and refunds its contributors.
+	ExpiredBountiesRefund = "refund"
+)
+
+const (
+	// TipAnnouncementsChannel posts tips to the channel they were sent in.
+	TipAnnouncementsChannel = "channel"
+	// TipAnnouncementsDm sends tips to the recipient as a direct message.
+	TipAnnouncementsDm = "dm"
+)
+
+const (
+	// AccountScopeChannel gives users a separate account in each channel.
+	AccountScopeChannel = "channel"
+	// AccountScopeWorkspace gives users a single wallet that is shared by every channel in the workspace.
+	AccountScopeWorkspace = "workspace"
+)
+
+// Validate checks the settings that can't be used as configured, the bot shouldn't start until they're fixed.
+func (c *Config) Validate() error {
+	switch c.DecayMode {
+	case types.DecayModeFlat, types.DecayModePercentage, types.DecayModeTiered:
+	default:
+		return fmt.Errorf("DecayMode must be %q, %q or %q: %q", types.DecayModeFlat, types.DecayModePercentage, types.DecayModeTiered, c.DecayMode)
+	}
+
+	if c.BalanceDecayPercentage < 0 || c.BalanceDecayPercentage > 100 {
+		return fmt.Errorf("BalanceDecayPercentage must be between 0 and 100: %v", c.BalanceDecayPercentage)
+	}
+
+	for _, tier := range c.DecayTiers {
+		if tier.Percentage < 0 || tier.Percentage > 100 {
+			return fmt.Errorf("DecayTiers percentages must be between 0 and 100: %v", tier.Percentage)
+		}
 	}
+
+	if c.SeasonBalanceResetPercentage < 0 || c.SeasonBalanceResetPercentage > 100 {
+		return fmt.Errorf("SeasonBalanceResetPercentage must be between 0 and 100: %v", c.SeasonBalanceResetPercentage)
+	}
+
+	for _, season := range c.Seasons {
+		if season.Name == "" {
+			return fmt.Errorf("Seasons must have a name: %v - %v", season.Start, season.End)
+		}
+
+		start, err := time.Parse(seasonDateLayout, season.Start)
+		if err != nil {
+			return fmt.Errorf("Seasons start dates must be formatted as YYYY-MM-DD: %v, %q", season.Name, season.Start)
+		}
+
+		end, err := time.Parse(seasonDateLayout, season.End)
+		if err != nil {
+			return fmt.Errorf("Seasons end dates must be formatted as YYYY-MM-DD: %v, %q", season.Name, season.End)
+		}
+
+		if end.Before(start) {
+			return fmt.Errorf("Seasons can't end before they start: %v", season.Name)
+		}
+	}
+
+	return nil
+}
+
+// AccountChannelId returns the channel id of the accounts used in the channel, this is the workspace wallet's id when
+// the account scope is "workspace".
+func (c *Config) AccountChannelId(channelId string) string {
+	if c.AccountScope == AccountScopeWorkspace {
+		return types.WorkspaceChannelId
+	}
+
+	return channelId
 }
 
 type BoostReactionValue struct {
 	Emote      string
 	BoostValue int
 }
+
+// FastReviewBonus multiplies the recipient's share of a bounty when they claimed it within the window, e.g. a
+// multiplier of 1.5 pays a bonus of half the share.
+type FastReviewBonus struct {
+	WithinMinutes int
+	Multiplier    float64
+}
+
+// Season is a named period, its start and end dates are inclusive and formatted as "2006-01-02".
+type Season struct {
+	Name  string
+	Start string
+	End   string
+}
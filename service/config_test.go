@@ -0,0 +1,118 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/buzzology/slack_bot/types"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "percentage mode",
+			modify: func(c *Config) {
+				c.DecayMode = types.DecayModePercentage
+				c.BalanceDecayPercentage = 100
+			},
+		},
+		{
+			name: "unknown decay mode",
+			modify: func(c *Config) {
+				c.DecayMode = "percent"
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty decay mode",
+			modify: func(c *Config) {
+				c.DecayMode = ""
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative decay percentage",
+			modify: func(c *Config) {
+				c.BalanceDecayPercentage = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "decay percentage over 100",
+			modify: func(c *Config) {
+				c.BalanceDecayPercentage = 101
+			},
+			wantErr: true,
+		},
+		{
+			name: "decay tier over 100",
+			modify: func(c *Config) {
+				c.DecayMode = types.DecayModeTiered
+				c.DecayTiers = []*types.DecayTier{{MinBalance: 10, Percentage: 150}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "seasons",
+			modify: func(c *Config) {
+				c.Seasons = []*Season{
+					{Name: "Q1", Start: "2026-01-01", End: "2026-03-31"},
+					{Name: "Launch day", Start: "2026-04-01", End: "2026-04-01"},
+				}
+			},
+		},
+		{
+			name: "season without a name",
+			modify: func(c *Config) {
+				c.Seasons = []*Season{{Start: "2026-01-01", End: "2026-03-31"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "season with an invalid date",
+			modify: func(c *Config) {
+				c.Seasons = []*Season{{Name: "Q1", Start: "2026-01-01", End: "31/03/2026"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "season that ends before it starts",
+			modify: func(c *Config) {
+				c.Seasons = []*Season{{Name: "Q1", Start: "2026-03-31", End: "2026-01-01"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "season balance reset over 100",
+			modify: func(c *Config) {
+				c.SeasonBalanceResetPercentage = 101
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative season balance reset",
+			modify: func(c *Config) {
+				c.SeasonBalanceResetPercentage = -5
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewConfig()
+			tt.modify(config)
+
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// AccountTransactionTypeSpend is a boost paid for from the account.
+	AccountTransactionTypeSpend = "spend"
+	// AccountTransactionTypeAward is a bounty paid out to the account.
+	AccountTransactionTypeAward = "award"
+	// AccountTransactionTypeRefund is a boost returned to the account.
+	AccountTransactionTypeRefund = "refund"
+	// AccountTransactionTypeDecay is the daily decay.
+	AccountTransactionTypeDecay = "decay"
+	// AccountTransactionTypeIncome is the daily income (and starting balance).
+	AccountTransactionTypeIncome = "income"
+	// AccountTransactionTypeBonus is a fast review bonus paid on top of a bounty.
+	AccountTransactionTypeBonus = "bonus"
+	// AccountTransactionTypeTip is points sent to (negative) or received from (positive) another account.
+	AccountTransactionTypeTip = "tip"
+	// AccountTransactionTypeAdjustment is a manual change to the balance, opening balances and carried forward totals.
+	AccountTransactionTypeAdjustment = "adjustment"
+)
+
+type AccountTransaction struct {
+	Id int
+	// TeamId is the workspace to which the account belongs.
+	TeamId string
+	// ChannelAccountId is the account whose balance changed.
+	ChannelAccountId int
+	// Type is one of the AccountTransactionType values.
+	Type string
+	// Amount is the change to the balance, negative amounts were taken from the account.
+	Amount int
+	// Reason is a short description of the change.
+	Reason string
+	// MessageId is the ts value of the bounty's message, if the change relates to one.
+	MessageId string
+	// Created is when the balance changed.
+	Created timestamppb.Timestamp
+}
+
+type ListAccountTransactionsFilter struct {
+	TeamId           string
+	ChannelAccountId int
+	Type             string
+	MessageId        string
+}
+
+// AccountReconciliation is an account whose balance doesn't match the sum of its transactions.
+type AccountReconciliation struct {
+	ChannelAccountId int
+	Balance          int
+	LedgerBalance    int
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// AchievementTypeFirstReviewOfTheDay is earned by the recipients of the first bounty awarded in a channel each day.
+	AchievementTypeFirstReviewOfTheDay = "first_review_of_the_day"
+	// AchievementTypeReviewStreak is earned each time a user's run of days with an awarded bounty reaches a multiple
+	// of the streak length.
+	AchievementTypeReviewStreak = "review_streak"
+	// AchievementTypeTenBounties is earned once a user has been awarded ten bounties in a channel.
+	AchievementTypeTenBounties = "ten_bounties"
+	// AchievementTypeHundredBounties is earned once a user has been awarded one hundred bounties in a channel.
+	AchievementTypeHundredBounties = "hundred_bounties"
+	// AchievementTypeDailyChampion is earned by the top of the daily leaderboard.
+	AchievementTypeDailyChampion = "daily_champion"
+)
+
+type Achievement struct {
+	Id int
+	// TeamId is the workspace to which the channel belongs.
+	TeamId string
+	// ChannelId is the channel the achievement was earned in.
+	ChannelId string
+	// UserId is the user that earned the achievement.
+	UserId string
+	// Type is what was achieved, e.g. review_streak.
+	Type string
+	// MessageId is the bounty that the achievement was earned with, empty if it was earned during the tickover.
+	MessageId string
+	// Created is when the achievement was earned.
+	Created timestamppb.Timestamp
+}
+
+// AchievementCount is the number of times a user has earned an achievement.
+type AchievementCount struct {
+	Type  string
+	Count int
+	// LastEarned is when the achievement was most recently earned.
+	LastEarned time.Time
+}
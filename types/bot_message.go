@@ -4,6 +4,7 @@ import "google.golang.org/protobuf/types/known/timestamppb"
 
 type BotMessage struct {
 	Id            int64                 `json:"id"`
+	TeamId        string                `json:"team_id"`
 	MessageId     string                `json:"message_id"`
 	SentMessageId string                `json:"sent_message_id"`
 	ChannelId     string                `json:"channel_id"`
@@ -16,6 +17,7 @@ type BotMessage struct {
 
 type ListBotMessagesFilter struct {
 	Id            string
+	TeamId        string
 	MessageId     string
 	SentMessageId string
 	UserId        string
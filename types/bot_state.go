@@ -11,4 +11,6 @@ type BotState struct {
 	WeekTickover  timestamppb.Timestamp
 	MonthTickover timestamppb.Timestamp
 	YearTickover  timestamppb.Timestamp
+	// Season is the name of the season that was in progress at the last tickover, empty if there wasn't one.
+	Season string
 }
@@ -0,0 +1,55 @@
+package types
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// BountyClaimStatusActive means the user has claimed the bounty and is waiting for it to be awarded.
+	BountyClaimStatusActive = 1
+	// BountyClaimStatusRetracted means the user removed their claim.
+	BountyClaimStatusRetracted = 2
+	// BountyClaimStatusAwarded means the user was paid a share of the bounty.
+	BountyClaimStatusAwarded = 3
+)
+
+type BountyClaim struct {
+	Id int
+	// TeamId is the workspace to which the bounty belongs.
+	TeamId string
+	// MessageId is the ts value of the slack message the bounty is on.
+	MessageId string
+	// ChannelId is the channel to which the bounty belongs.
+	ChannelId string
+	// UserId is the user claiming the bounty.
+	UserId string
+	// Status is either active/retracted/awarded.
+	Status int
+	// Amount is the user's share of the bounty once it has been awarded.
+	Amount int
+	// Bonus is the fast review bonus that was paid on top of the share.
+	Bonus int
+	// ClaimedAt is when the bounty was last claimed, it's reset if the user claims it again after retracting.
+	ClaimedAt timestamppb.Timestamp
+	// Created is when the bounty was first claimed.
+	Created timestamppb.Timestamp
+	// Updated is when the claim was last updated.
+	Updated timestamppb.Timestamp
+}
+
+type ListBountyClaimsFilter struct {
+	TeamId    string
+	MessageId string
+	ChannelId string
+	UserId    string
+	Status    int
+}
+
+// BountyPayout is the share of a bounty paid to a single user.
+type BountyPayout struct {
+	UserId           string
+	ChannelAccountId int
+	Amount           int
+	// Bonus is minted on top of the share for a fast review, it isn't taken from the bounty.
+	Bonus int
+}
@@ -4,8 +4,13 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// WorkspaceChannelId is the channel id given to workspace wallets, the account a user spends and earns from in every
+// channel when the account scope is "workspace".
+const WorkspaceChannelId = "workspace"
+
 type ChannelAccount struct {
 	Id             int
+	TeamId         string
 	UserId         string
 	ChannelId      string
 	Balance        int
@@ -15,14 +20,20 @@ type ChannelAccount struct {
 	SpentThisWeek  int
 	EarnedThisYear int
 	SpentThisYear  int
-	EarnedAllTime  int
-	SpentAllTime   int
-	Created        timestamppb.Timestamp
-	Updated        timestamppb.Timestamp
+	// EarnedThisSeason and SpentThisSeason are reset when a season ends.
+	EarnedThisSeason int
+	SpentThisSeason  int
+	EarnedAllTime    int
+	SpentAllTime     int
+	Frozen           bool
+	Created          timestamppb.Timestamp
+	Updated          timestamppb.Timestamp
 }
 
 type ListChannelAccountsFilter struct {
-	Id        int
-	UserId    string
-	ChannelId string
+	Id            int
+	TeamId        string
+	UserId        string
+	ChannelId     string
+	ExcludeFrozen bool
 }
@@ -0,0 +1,38 @@
+package types
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ChannelSettings overrides the economy settings in the config for a single channel. Nil and empty values mean the
+// channel uses the config's value.
+type ChannelSettings struct {
+	// TeamId is the workspace to which the channel belongs.
+	TeamId string
+	// ChannelId is the channel the settings apply to.
+	ChannelId string
+	// DailyDecay is deducted from each account in the channel on the daily tickover.
+	DailyDecay *int
+	// DailyIncome is added to each account in the channel on the daily tickover.
+	DailyIncome *int
+	// BoostReactions is a comma separated list of emote:value pairs, e.g. "dollar:1,moneybag:5".
+	BoostReactions string
+	// TaskCompletedByMeReaction is the reaction used to claim a bounty.
+	TaskCompletedByMeReaction string
+	// ReleaseBountyReaction is the reaction used to award a bounty.
+	ReleaseBountyReaction string
+	// MinBounty is the smallest a bounty can be once it has been boosted, zero means no minimum.
+	MinBounty *int
+	// MaxBounty is the largest a bounty can be boosted to, zero means no maximum.
+	MaxBounty *int
+	// MaxBoostPerUser is the most each user can contribute to a single bounty, zero means no limit.
+	MaxBoostPerUser *int
+	// DailySpendCap is the most each user can spend on bounties each day, zero means no cap.
+	DailySpendCap *int
+	// UpdatedBy is the user that last changed the settings.
+	UpdatedBy string
+	// Created is when the channel's settings were first saved.
+	Created timestamppb.Timestamp
+	// Updated is when the channel's settings were last changed.
+	Updated timestamppb.Timestamp
+}
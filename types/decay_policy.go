@@ -0,0 +1,30 @@
+package types
+
+const (
+	// DecayModeFlat deducts the same amount from every balance.
+	DecayModeFlat = "flat"
+	// DecayModePercentage deducts a percentage of every balance.
+	DecayModePercentage = "percentage"
+	// DecayModeTiered deducts a percentage of each balance based on the highest tier it has reached.
+	DecayModeTiered = "tiered"
+)
+
+// DecayPolicy describes how balances decay and how high they can grow on the daily tickover.
+type DecayPolicy struct {
+	// Mode is either flat/percentage/tiered.
+	Mode string
+	// Amount is the flat decay, channels with their own daily decay use theirs instead.
+	Amount int
+	// Percentage is the percentage of the balance that decays in percentage mode.
+	Percentage int
+	// Tiers are the percentages that apply in tiered mode.
+	Tiers []*DecayTier
+	// MaxBalance caps balances once the income has been applied, zero means no cap.
+	MaxBalance int
+}
+
+// DecayTier is the percentage that decays once a balance has reached the minimum.
+type DecayTier struct {
+	MinBalance int
+	Percentage int
+}
@@ -0,0 +1,26 @@
+package types
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Installation struct {
+	// TeamId is the workspace the bot has been installed to.
+	TeamId string
+	// TeamName is the name of the workspace at the time of installation.
+	TeamName string
+	// AppId is the id of the slack app that was installed.
+	AppId string
+	// BotUserId is the user id of the bot within the workspace.
+	BotUserId string
+	// BotToken is the xoxb token used to call the slack api for the workspace.
+	BotToken string
+	// Scope is the comma separated list of scopes that were granted.
+	Scope string
+	// InstalledBy is the user that installed the bot.
+	InstalledBy string
+	// Created is when the bot was first installed to the workspace.
+	Created timestamppb.Timestamp
+	// Updated is when the bot was last installed to the workspace.
+	Updated timestamppb.Timestamp
+}
@@ -1,22 +1,39 @@
 package types
 
 import (
+	"time"
+
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+const (
+	// MessageBountyStatusOpen means the bounty can still be boosted, claimed and awarded.
+	MessageBountyStatusOpen = 1
+	// MessageBountyStatusAwarded means the bounty has been paid out.
+	MessageBountyStatusAwarded = 2
+	// MessageBountyStatusCancelled means the bounty was withdrawn and its contributors refunded.
+	MessageBountyStatusCancelled = 3
+	// MessageBountyStatusExpired means the bounty was left open too long and was paid out to its claimants or refunded.
+	MessageBountyStatusExpired = 4
+)
+
 type MessageBounty struct {
 	// MessageId is the ts value of the slack message.
 	MessageId string
+	// TeamId is the workspace to which the bounty belongs.
+	TeamId string
 	// ChannelId is the channel to which the bounty belongs.
 	ChannelId string
 	// UserId should be the pull request (message's) creator.
 	UserId string
 	// CurrentBounty is the amount the will/was awarded to the reviewer.
 	CurrentBounty int
-	// Status is either open/closed.
+	// Status is either open/awarded/cancelled/expired.
 	Status int
 	// AwardedTo is the user that received the bounty.
 	AwardedTo string
+	// ExpiryWarned is set once the owner has been warned that the bounty is about to expire.
+	ExpiryWarned bool
 	// Created is when the message bounty was initially created.
 	Created timestamppb.Timestamp
 	// Updated is when the message bounty was updated.
@@ -25,6 +42,12 @@ type MessageBounty struct {
 
 type ListMessageBountiesFilter struct {
 	MessageId string
+	TeamId    string
 	UserId    string
 	ChannelId string
+	Status    int
+	// CreatedBefore only includes bounties created before this time when set.
+	CreatedBefore time.Time
+	// ExcludeExpiryWarned leaves out bounties whose owner has already been warned they're about to expire.
+	ExcludeExpiryWarned bool
 }
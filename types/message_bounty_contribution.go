@@ -0,0 +1,51 @@
+package types
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// MessageBountyContributionStatusActive means the points are currently part of the bounty.
+	MessageBountyContributionStatusActive = 1
+	// MessageBountyContributionStatusRefunded means the points have been returned to the contributor.
+	MessageBountyContributionStatusRefunded = 2
+)
+
+type MessageBountyContribution struct {
+	Id int
+	// TeamId is the workspace to which the bounty belongs.
+	TeamId string
+	// MessageId is the ts value of the slack message the bounty is on.
+	MessageId string
+	// ChannelId is the channel to which the bounty belongs.
+	ChannelId string
+	// UserId is the user that boosted the bounty.
+	UserId string
+	// ChannelAccountId is the account the points were spent from.
+	ChannelAccountId int
+	// Reaction is the boost emote that was used.
+	Reaction string
+	// Amount is the number of points that were added to the bounty.
+	Amount int
+	// Status is either active/refunded.
+	Status int
+	// Created is when the bounty was boosted.
+	Created timestamppb.Timestamp
+	// Updated is when the contribution was last updated.
+	Updated timestamppb.Timestamp
+}
+
+// BountyContributor is the total a single user has put towards a bounty.
+type BountyContributor struct {
+	UserId string
+	Amount int
+}
+
+type ListMessageBountyContributionsFilter struct {
+	TeamId    string
+	MessageId string
+	ChannelId string
+	UserId    string
+	Reaction  string
+	Status    int
+}
@@ -0,0 +1,18 @@
+package types
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type ProcessedEvent struct {
+	// EventId is the unique id slack assigns to each event, it is shared across retries.
+	EventId string
+	// EventType is the type of the inner event (reaction_added etc).
+	EventType string
+	// TeamId is the workspace that the event was received from.
+	TeamId string
+	// Created is when the event was first received.
+	Created timestamppb.Timestamp
+	// Updated is when the event was last received.
+	Updated timestamppb.Timestamp
+}
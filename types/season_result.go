@@ -0,0 +1,25 @@
+package types
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type SeasonResult struct {
+	Id int
+	// TeamId is the workspace to which the channel belongs.
+	TeamId string
+	// ChannelId is the channel of the account, the workspace wallet's id when accounts are workspace wide.
+	ChannelId string
+	// Season is the name of the season.
+	Season string
+	// UserId is the user the result is for.
+	UserId string
+	// Earned is the total the user earned during the season.
+	Earned int
+	// Spent is the total the user spent during the season.
+	Spent int
+	// Balance is the user's balance when the season ended.
+	Balance int
+	// Created is when the season was archived.
+	Created timestamppb.Timestamp
+}
@@ -0,0 +1,7 @@
+package types
+
+// TeamChannel is a channel along with the workspace it belongs to.
+type TeamChannel struct {
+	TeamId    string
+	ChannelId string
+}
@@ -0,0 +1,23 @@
+package types
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Tip struct {
+	Id int
+	// TeamId is the workspace to which the channel belongs.
+	TeamId string
+	// ChannelId is the channel both accounts belong to.
+	ChannelId string
+	// FromUserId is the user that sent the tip.
+	FromUserId string
+	// ToUserId is the user that received the tip.
+	ToUserId string
+	// Amount is the number of points sent.
+	Amount int
+	// Note is an optional message from the sender.
+	Note string
+	// Created is when the tip was sent.
+	Created timestamppb.Timestamp
+}